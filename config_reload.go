@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"superray-tui/pkg/configstore"
+	"superray-tui/pkg/superray"
+	"superray-tui/pkg/supervisor"
+)
+
+// startConfigReload registers the config-file watcher/SIGHUP-handler
+// service with the App's supervisor. Only called when a.configPath is
+// set (SUPERRAY_CONFIG_FILE); a bad path still just keeps failing and
+// getting restarted by the supervisor's backoff, same as any other
+// misconfigured service.
+func (a *App) startConfigReload() {
+	a.supervisor.Add("config-reload", supervisor.FuncService(a.runConfigReload))
+}
+
+// runConfigReload reloads the config file on SIGHUP or whenever it
+// changes on disk, until ctx is cancelled.
+func (a *App) runConfigReload(ctx context.Context) error {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	watchErr := make(chan error, 1)
+	safeGo(func() { watchErr <- configstore.Watch(ctx, a.configPath, a.reloadConfigFile) })
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sighup:
+			a.log("[yellow]SIGHUP received, reloading config[white]")
+			a.reloadConfigFile()
+		case err := <-watchErr:
+			return err
+		}
+	}
+}
+
+// configSnapshot captures the TUI-editable settings currently live in the
+// App, for diffing against a freshly loaded config file.
+func (a *App) configSnapshot() *configstore.Config {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return &configstore.Config{
+		SubscriptionURL: a.subscriptionURL,
+		LocalPort:       a.localPort,
+		DirectCountries: a.directCountries,
+		AccessLogPath:   a.accessLogPath,
+		ErrorLogPath:    a.errorLogPath,
+		TunMode:         a.tunMode,
+	}
+}
+
+// saveConfigFile persists the App's current TUI-editable settings to
+// a.configPath, so an interactive edit (new subscription URL, TUN mode
+// toggle, ...) survives a restart.
+func (a *App) saveConfigFile() {
+	if a.configPath == "" {
+		return
+	}
+	if err := configstore.Save(a.configPath, a.configSnapshot()); err != nil {
+		a.log(fmt.Sprintf("[red]Failed to save config: %v[white]", err))
+	}
+}
+
+// reloadConfigFile loads a.configPath, validates it, and applies only the
+// settings that actually changed: a bad file is rejected without
+// disturbing the live instance, and an untouched setting is left alone
+// rather than reapplied.
+func (a *App) reloadConfigFile() {
+	next, err := configstore.Load(a.configPath)
+	if err != nil {
+		a.log(fmt.Sprintf("[red]Config reload failed: %v[white]", err))
+		return
+	}
+	if err := configstore.Validate(next); err != nil {
+		a.log(fmt.Sprintf("[red]Config reload rejected, live instance untouched: %v[white]", err))
+		return
+	}
+
+	current := a.configSnapshot()
+	changed := configstore.Diff(current, next)
+	if len(changed) == 0 {
+		return
+	}
+	a.log(fmt.Sprintf("[yellow]Config file changed: %s[white]", strings.Join(changed, ", ")))
+
+	changedSet := make(map[string]bool, len(changed))
+	for _, c := range changed {
+		changedSet[c] = true
+	}
+
+	a.mu.Lock()
+	a.subscriptionURL = next.SubscriptionURL
+	a.localPort = next.LocalPort
+	a.directCountries = next.DirectCountries
+	a.accessLogPath = next.AccessLogPath
+	a.errorLogPath = next.ErrorLogPath
+	a.mu.Unlock()
+
+	if changedSet["access_log"] || changedSet["error_log"] {
+		globalErrorLogPath = next.ErrorLogPath
+	}
+	if changedSet["subscription_url"] && next.SubscriptionURL != "" {
+		safeGo(func() { a.loadSubscription() })
+	}
+	if changedSet["local_port"] || changedSet["direct_countries"] {
+		a.rebuildRunningInstance("config reload")
+	}
+	if changedSet["tun_mode"] {
+		safeGo(func() { a.toggleTunMode() })
+	}
+}
+
+// rebuildRunningInstance re-renders the Xray config for the currently
+// connected server and swaps the running instance to it in place: start
+// the new instance, rebind the TUN stack to it without tearing down the
+// TUN device or routes, then destroy the old instance. No-op when
+// disconnected - the new settings simply apply to the next connection.
+func (a *App) rebuildRunningInstance(reason string) {
+	a.mu.RLock()
+	connected := a.isConnected
+	server := a.currentServer
+	oldInstanceID := a.instanceID
+	tunMode := a.tunMode
+	a.mu.RUnlock()
+	if !connected || server == nil {
+		return
+	}
+
+	a.log(fmt.Sprintf("[yellow]Rebuilding running config (%s)...[white]", reason))
+
+	config := a.buildConfig(server)
+	newInstanceID, err := superray.Run(config)
+	if err != nil {
+		a.log(fmt.Sprintf("[red]Config rebuild failed, keeping existing instance: %v[white]", err))
+		return
+	}
+
+	if tunMode {
+		if err := superray.StartSystemTUNStack("tun0", newInstanceID, "proxy"); err != nil {
+			a.log(fmt.Sprintf("[red]Failed to rebind TUN stack to rebuilt instance: %v[white]", err))
+			superray.DestroyInstance(newInstanceID)
+			return
+		}
+	}
+
+	a.mu.Lock()
+	a.instanceID = newInstanceID
+	a.mu.Unlock()
+
+	superray.DestroyInstance(oldInstanceID)
+
+	a.stopFlowTracker()
+	a.startFlowTracker(newInstanceID)
+
+	a.mu.RLock()
+	sched := a.healthSched
+	a.mu.RUnlock()
+	if sched != nil {
+		sched.SetActive(server)
+	}
+
+	a.log("[green]Config rebuilt in place[white]")
+}
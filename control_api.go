@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+
+	"superray-tui/pkg/controlapi"
+	"superray-tui/pkg/flows"
+	"superray-tui/pkg/superray"
+)
+
+// startControlAPI starts the optional Clash-compatible control API if
+// SUPERRAY_API_ADDR is configured. It runs for the lifetime of the
+// process; there is no separate stop path since it shares App's state
+// and is harmless once the TUI itself exits.
+func (a *App) startControlAPI() {
+	if a.apiAddr == "" {
+		return
+	}
+
+	server := controlapi.NewServer(controlapi.Options{
+		Addr:   a.apiAddr,
+		Secret: a.apiSecret,
+	}, a)
+
+	a.log(fmt.Sprintf("[green]Control API listening on %s[white]", a.apiAddr))
+	safeGo(func() {
+		if err := server.ListenAndServe(); err != nil {
+			a.log(fmt.Sprintf("[red]Control API stopped: %v[white]", err))
+		}
+	})
+}
+
+// Version implements controlapi.State.
+func (a *App) Version() (superrayVer, xrayVer string) {
+	superrayVer, _ = superray.Version()
+	xrayVer, _ = superray.XrayVersion()
+	return
+}
+
+// Proxies implements controlapi.State.
+func (a *App) Proxies() []controlapi.ProxyInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	proxies := make([]controlapi.ProxyInfo, 0, len(a.servers))
+	for _, s := range a.servers {
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("%s:%d", s.Address, s.Port)
+		}
+		proxies = append(proxies, controlapi.ProxyInfo{
+			Name:    name,
+			Type:    s.Protocol,
+			Address: fmt.Sprintf("%s:%d", s.Address, s.Port),
+			Now:     a.currentServer != nil && a.currentServer.Address == s.Address && a.currentServer.Port == s.Port,
+			Delay:   int(s.Latency),
+		})
+	}
+	return proxies
+}
+
+// SwitchProxy implements controlapi.State, switching the active server by
+// name (matching the display name used in Proxies).
+func (a *App) SwitchProxy(name string) error {
+	a.mu.RLock()
+	index := -1
+	for i, s := range a.servers {
+		candidate := s.Name
+		if candidate == "" {
+			candidate = fmt.Sprintf("%s:%d", s.Address, s.Port)
+		}
+		if candidate == name {
+			index = i
+			break
+		}
+	}
+	a.mu.RUnlock()
+
+	if index == -1 {
+		return fmt.Errorf("unknown proxy %q", name)
+	}
+	a.connectToServer(index)
+	return nil
+}
+
+// Connections implements controlapi.State, reporting the live per-flow
+// view from the flow tracker.
+func (a *App) Connections() []controlapi.ConnectionInfo {
+	a.mu.RLock()
+	tracker := a.flowTracker
+	a.mu.RUnlock()
+
+	if tracker == nil {
+		return nil
+	}
+
+	snapshot := tracker.Snapshot(flows.Filter{}, flows.SortByStart)
+	conns := make([]controlapi.ConnectionInfo, 0, len(snapshot))
+	for _, f := range snapshot {
+		c := controlapi.ConnectionInfo{ID: f.ID, Upload: f.Upload, Download: f.Download, Start: f.Start}
+		c.Metadata.Network = f.Network
+		c.Metadata.Destination = f.Host
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// CloseConnection implements controlapi.State, killing the flow through
+// the flow tracker.
+func (a *App) CloseConnection(id string) error {
+	a.mu.RLock()
+	tracker := a.flowTracker
+	a.mu.RUnlock()
+
+	if tracker == nil {
+		return fmt.Errorf("no active flow tracker")
+	}
+	return tracker.Kill(id)
+}
+
+// Traffic implements controlapi.State, reporting the current per-second
+// rates (not the cumulative totals) since /traffic is streamed once a
+// second for live speed graphs.
+func (a *App) Traffic() (up, down int64) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return int64(a.uploadSpeed), int64(a.downloadSpeed)
+}
+
+// Subscribe implements controlapi.State, registering a new /logs
+// WebSocket subscriber.
+func (a *App) Subscribe() (<-chan string, func()) {
+	ch := make(chan string, 64)
+
+	a.logSubsMu.Lock()
+	a.logSubs = append(a.logSubs, ch)
+	a.logSubsMu.Unlock()
+
+	cancel := func() {
+		a.logSubsMu.Lock()
+		defer a.logSubsMu.Unlock()
+		for i, c := range a.logSubs {
+			if c == ch {
+				a.logSubs = append(a.logSubs[:i], a.logSubs[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"superray-tui/pkg/fakedns"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showFakeDNSPanel lists the live fake-IP allocations (domain -> fake
+// IP) for both pools, most-recently-used first. With fake-DNS disabled
+// or not yet started (only runs while connected in TUN mode), it
+// explains how to enable it. Esc closes.
+func (a *App) showFakeDNSPanel() {
+	view := tview.NewTextView().SetDynamicColors(true)
+	view.SetBorder(true).SetTitle(" Fake-IP DNS Allocations [Esc:Close] ")
+
+	a.renderFakeDNSPanel(view)
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			a.pages.RemovePage("fakedns")
+			return nil
+		}
+		return event
+	})
+
+	a.pages.AddPage("fakedns", view, true, true)
+	a.app.SetFocus(view)
+}
+
+func (a *App) renderFakeDNSPanel(view *tview.TextView) {
+	a.mu.RLock()
+	enabled := a.fakeDNSEnabled
+	resolver := a.fakeDNSResolver
+	cfg := a.fakeDNSCfg
+	a.mu.RUnlock()
+
+	var b strings.Builder
+	if !enabled {
+		fmt.Fprintf(&b, "[yellow]Fake-DNS disabled[white]\n\n")
+		fmt.Fprintf(&b, "Set FAKE_DNS_ENABLED=1 to allocate synthetic addresses\n")
+		fmt.Fprintf(&b, "for non-direct domains while in TUN mode.\n")
+		view.SetText(b.String())
+		return
+	}
+	if resolver == nil {
+		fmt.Fprintf(&b, "[yellow]Fake-DNS enabled but not running[white]\n\n")
+		fmt.Fprintf(&b, "It starts when TUN mode is turned on ('u').\n")
+		view.SetText(b.String())
+		return
+	}
+
+	fmt.Fprintf(&b, "[yellow]Listen:[white] %s   [yellow]v4:[white] %s   [yellow]v6:[white] %s   [yellow]TTL:[white] %s\n\n",
+		cfg.Listen, cfg.IPv4Range, cfg.IPv6Range, cfg.TTL)
+
+	fmt.Fprintf(&b, "[green]IPv4 pool[white]\n")
+	renderAllocations(&b, resolver.V4().Allocations())
+
+	if v6 := resolver.V6(); v6 != nil {
+		fmt.Fprintf(&b, "\n[green]IPv6 pool[white]\n")
+		renderAllocations(&b, v6.Allocations())
+	}
+
+	view.SetText(b.String())
+}
+
+func renderAllocations(b *strings.Builder, allocs []fakedns.Allocation) {
+	if len(allocs) == 0 {
+		fmt.Fprintf(b, " (none yet)\n")
+		return
+	}
+	for _, alloc := range allocs {
+		fmt.Fprintf(b, " %-15s  %s\n", alloc.IP, alloc.Domain)
+	}
+}
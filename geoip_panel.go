@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"superray-tui/pkg/geoip"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showGeoIPPanel shows the configured GeoIP provider chain, the on-disk
+// cache status, and the resolved MMDB directory, with 'r' to rebuild the
+// chain (re-opening the MMDB files) from the settings loaded at startup
+// and Esc to close. 'r' does not re-read .env or the environment.
+func (a *App) showGeoIPPanel() {
+	view := tview.NewTextView().SetDynamicColors(true)
+	view.SetBorder(true).SetTitle(" GeoIP [r:Reload Esc:Close] ")
+
+	a.renderGeoIPPanel(view)
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			a.pages.RemovePage("geoip")
+			return nil
+		case event.Rune() == 'r' || event.Rune() == 'R':
+			a.setupGeoIP()
+			a.log("[green]GeoIP provider chain reloaded[white]")
+			a.renderGeoIPPanel(view)
+			return nil
+		}
+		return event
+	})
+
+	a.pages.AddPage("geoip", view, true, true)
+	a.app.SetFocus(view)
+}
+
+func (a *App) renderGeoIPPanel(view *tview.TextView) {
+	a.mu.RLock()
+	providers := a.geoProviders
+	mmdbDir := a.geoMMDBDir
+	if mmdbDir == "" {
+		mmdbDir = a.geoPath
+	}
+	ttlMin := a.geoCacheTTLMin
+	a.mu.RUnlock()
+
+	if providers == "" {
+		providers = "mmdb,ipapi (default)"
+	}
+	ttl := "30 (default)"
+	if ttlMin > 0 {
+		ttl = fmt.Sprintf("%d", ttlMin)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[yellow]Provider chain[white]\n")
+	if geoChain != nil {
+		fmt.Fprintf(&b, " Configured: %s\n Active:     %s\n\n", providers, strings.Join(geoChain.Names(), ", "))
+	} else {
+		fmt.Fprintf(&b, " Configured: %s\n Active:     [darkgray]not initialized[white]\n\n", providers)
+	}
+
+	fmt.Fprintf(&b, "[yellow]Offline lookup[white]\n")
+	fmt.Fprintf(&b, " MMDB directory: %s\n\n", mmdbDir)
+
+	fmt.Fprintf(&b, "[yellow]Cache[white]\n")
+	fmt.Fprintf(&b, " Path: %s\n TTL:  %s min\n", geoip.DefaultCachePath(), ttl)
+	if geoCache != nil {
+		fmt.Fprintf(&b, " Entries: %d\n", geoCache.Len())
+	}
+
+	view.SetText(b.String())
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"superray-tui/pkg/healthcheck"
+	"superray-tui/pkg/superray"
+	"superray-tui/pkg/supervisor"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// startHealthScheduler (re)starts the health-probe scheduler for the
+// current server list, registering it with the App's supervisor under a
+// fixed name so re-registering (e.g. after a subscription refresh changes
+// the server set) stops the previous scheduler instead of leaking it.
+func (a *App) startHealthScheduler() {
+	a.mu.Lock()
+	servers := a.servers
+	sched := healthcheck.NewScheduler(servers, 0, 4)
+	a.healthSched = sched
+	a.mu.Unlock()
+
+	a.supervisor.Add("health-probes", supervisor.FuncService(func(ctx context.Context) error {
+		sched.Run(ctx)
+		return nil
+	}))
+}
+
+// toggleAutoFailover flips Auto mode, which switches away from the active
+// server automatically when the health scheduler judges it degraded.
+func (a *App) toggleAutoFailover() {
+	a.mu.Lock()
+	a.autoFailover = !a.autoFailover
+	enabled := a.autoFailover
+	sched := a.healthSched
+	a.mu.Unlock()
+
+	if sched == nil {
+		a.log("[yellow]No health data yet; load servers first[white]")
+		return
+	}
+
+	if enabled {
+		sched.SetAuto(true, a.onAutoFailover)
+		a.log("[green]Auto mode enabled: will switch away from a degraded server[white]")
+	} else {
+		sched.SetAuto(false, nil)
+		a.log("[yellow]Auto mode disabled[white]")
+	}
+}
+
+// onAutoFailover is invoked by the health scheduler when the active
+// server's score drops below the floor or fails too many consecutive
+// probes.
+func (a *App) onAutoFailover(next *superray.Server, reason string) {
+	a.log(fmt.Sprintf("[red]Auto-failover: %s[white]", reason))
+	a.log(fmt.Sprintf("[yellow]Switching to %s[white]", next.Name))
+
+	a.mu.RLock()
+	index := -1
+	for i, s := range a.servers {
+		if s.Address == next.Address && s.Port == next.Port {
+			index = i
+			break
+		}
+	}
+	a.mu.RUnlock()
+
+	if index >= 0 {
+		safeGo(func() { a.connectToServer(index) })
+	}
+}
+
+// showServerDetailPopup shows the health-score breakdown for the
+// currently selected server.
+func (a *App) showServerDetailPopup() {
+	a.mu.RLock()
+	sched := a.healthSched
+	var server *superray.Server
+	if a.selectedIndex >= 0 && a.selectedIndex < len(a.servers) {
+		server = a.servers[a.selectedIndex]
+	}
+	a.mu.RUnlock()
+
+	if server == nil || sched == nil {
+		a.log("[yellow]No health data for this server yet[white]")
+		return
+	}
+
+	score := sched.Score(server)
+	text := fmt.Sprintf(
+		"[yellow]%s[white]\n\nSamples: %d\nSuccess rate: %.0f%%\nMedian RTT: %.0fms\nJitter: %.0fms\nScore: %.1f\n\n[darkgray]Esc to close[white]",
+		server.Name, score.Samples, score.SuccessRate*100, score.MedianRTTMs, score.JitterMs, score.Value)
+
+	view := tview.NewTextView().SetDynamicColors(true).SetText(text)
+	view.SetBorder(true).SetTitle(" Server Health ")
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(view, 11, 0, true).
+			AddItem(nil, 0, 1, false), 50, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			a.pages.RemovePage("modal")
+			return nil
+		}
+		return event
+	})
+
+	a.pages.AddPage("modal", modal, true, true)
+	a.app.SetFocus(view)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -10,11 +11,21 @@ import (
 	"path/filepath"
 	"runtime/debug"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"superray-tui/pkg/configstore"
+	"superray-tui/pkg/dnsresolver"
+	"superray-tui/pkg/fakedns"
+	"superray-tui/pkg/flows"
+	"superray-tui/pkg/geoip"
+	"superray-tui/pkg/healthcheck"
+	"superray-tui/pkg/proxygroup"
+	"superray-tui/pkg/ruleset"
 	"superray-tui/pkg/superray"
+	"superray-tui/pkg/supervisor"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -46,6 +57,7 @@ type App struct {
 	downloadSpeed   float64
 	lastUpdateTime  time.Time
 	lastStats       *superray.TrafficStats
+	connectedAt     time.Time
 
 	// Traffic history for chart
 	trafficHistory []TrafficPoint
@@ -65,6 +77,127 @@ type App struct {
 
 	// GeoIP info for selected server
 	serverGeoInfo *GeoIPInfo
+
+	// GeoIP provider chain config
+	geoProviders   string
+	geoTokenIPInfo string
+	geoMMDBDir     string
+	geoCacheTTLMin int
+
+	// Clash-compatible control API config
+	apiAddr   string
+	apiSecret string
+
+	// Xray-core's own Commander gRPC API (stats/handler/routing/log),
+	// distinct from the Clash-compatible control API above. Empty
+	// disables it: buildConfig omits the "api" block and inbound.
+	xrayAPIListen string
+
+	// Log line subscribers for the control API's /logs WebSocket
+	logSubsMu sync.Mutex
+	logSubs   []chan string
+
+	// Per-flow connection tracking (replaces the old aggregated-only
+	// connList view)
+	flowTracker    *flows.Tracker
+	flowCancel     context.CancelFunc
+	flowFilter     flows.Filter
+	flowSort       flows.SortMode
+	flowTopTalkers bool
+
+	// Health-probe scheduler and auto-failover (the scheduler itself runs
+	// as a supervised Service; see startHealthScheduler)
+	healthSched  *healthcheck.Scheduler
+	autoFailover bool
+
+	// Rule-provider subsystem config
+	ruleManifestPath string
+	ruleManager      *ruleset.Manager
+	ruleCancel       context.CancelFunc
+
+	// Outbound selector groups (url-test/fallback/load-balance/select)
+	groupManifestPath string
+	groupManager      *proxygroup.Manager
+	groupCancel       context.CancelFunc
+
+	// Traffic sniffing, applied to every inbound buildConfig emits
+	sniffing SniffingConfig
+
+	// Fake-IP DNS resolver for TUN mode
+	fakeDNSEnabled  bool
+	fakeDNSCfg      fakedns.Config
+	fakeDNSResolver *fakedns.Resolver
+	fakeDNSCancel   context.CancelFunc
+
+	// Encrypted upstream DNS (DoH/DoT/DoQ) for LookupHostSecure/the
+	// global resolver, configured as "protocol:address" pairs, e.g.
+	// "doh:https://1.1.1.1/dns-query,dot:9.9.9.9:853"
+	dnsUpstreams    string
+	dnsClientSubnet string
+
+	// Supervisor restarts the App's background services (stats poller,
+	// subscription auto-updater, GeoIP cache maintainer, health probes)
+	// with exponential backoff instead of letting a panic or clean exit
+	// silently go dark. TUN packet forwarding itself isn't a supervised
+	// Service: it runs inside the Xray/TUN-stack native bridge, not a Go
+	// goroutine this process controls.
+	supervisor        *supervisor.Supervisor
+	supervisorCancel  context.CancelFunc
+	subAutoRefreshMin int
+
+	// Path to the YAML file that mirrors the TUI-editable settings
+	// (configSnapshot/saveConfigFile/reloadConfigFile), watched for
+	// hot reload. Empty disables both persistence and reload.
+	configPath string
+}
+
+// SniffingConfig mirrors Xray's per-inbound "sniffing" object: it lets
+// routing rules match on the sniffed domain (HTTP Host/TLS SNI/QUIC)
+// instead of only the destination IP, which matters most for TUN/SOCKS
+// traffic that arrives addressed to an IP.
+type SniffingConfig struct {
+	Enabled         bool
+	DestOverride    []string // "http", "tls", "quic", "fakedns"
+	MetadataOnly    bool     // only use already-known metadata, skip payload inspection
+	RouteOnly       bool     // sniff for routing decisions but keep the original destination for the outbound
+	DomainsExcluded []string // domains routed by original destination even when sniffed
+}
+
+// toXray renders c as the JSON object buildConfig embeds under an
+// inbound's "sniffing" key, or nil if sniffing is disabled (omitting the
+// key entirely, as Xray expects, rather than emitting "enabled": false).
+func (c SniffingConfig) toXray() map[string]interface{} {
+	if !c.Enabled {
+		return nil
+	}
+	m := map[string]interface{}{
+		"enabled":      true,
+		"destOverride": c.DestOverride,
+		"metadataOnly": c.MetadataOnly,
+		"routeOnly":    c.RouteOnly,
+	}
+	if len(c.DomainsExcluded) > 0 {
+		m["domainsExcluded"] = c.DomainsExcluded
+	}
+	return m
+}
+
+// tunSniffing adds "fakedns" to base's destOverride for the TUN inbound
+// when fake-IP DNS is enabled, since that's the only inbound whose
+// traffic is addressed by IP rather than already carrying a domain (as
+// SOCKS/HTTP CONNECT targets do). base is left untouched; a shallow copy
+// is returned.
+func (a *App) tunSniffing(base map[string]interface{}) map[string]interface{} {
+	if base == nil || !a.fakeDNSEnabled {
+		return base
+	}
+	destOverride := append([]string{"fakedns"}, a.sniffing.DestOverride...)
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+	out["destOverride"] = destOverride
+	return out
 }
 
 // TrafficPoint represents a point in traffic history
@@ -98,6 +231,13 @@ var (
 	cacheTTL    = 30 * time.Minute
 )
 
+// geoChain and geoCache back lookupIP once setupGeoIP has run; until then
+// lookupIP falls back to the legacy direct ip-api.com query.
+var (
+	geoChain *geoip.Chain
+	geoCache *geoip.Cache
+)
+
 // Global log path for panic handlers
 var globalErrorLogPath = "error.log"
 
@@ -150,6 +290,18 @@ func NewApp() *App {
 		lastUpdateTime:  time.Now(),
 		historyMaxLen:   300,
 		trafficHistory:  make([]TrafficPoint, 0, 300),
+		sniffing: SniffingConfig{
+			Enabled:      true,
+			DestOverride: []string{"http", "tls", "quic"},
+		},
+		fakeDNSCfg: fakedns.Config{
+			Listen:        "127.0.0.1:10853",
+			IPv4Range:     "198.18.0.0/15",
+			IPv6Range:     "fc00::/18",
+			TTL:           60 * time.Second,
+			DirectDomains: []string{"cn"},
+		},
+		supervisor: supervisor.New(),
 	}
 }
 
@@ -193,6 +345,19 @@ func extractLatency(name string) int {
 	return latency
 }
 
+// splitCSV splits a comma-separated env value into trimmed, non-empty
+// parts.
+func splitCSV(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
 // loadEnvConfig loads configuration from .env file
 func (a *App) loadEnvConfig() {
 	envPaths := []string{
@@ -226,8 +391,121 @@ func (a *App) loadEnvConfig() {
 		}
 	}
 
+	if providers := os.Getenv("GEOIP_PROVIDERS"); providers != "" {
+		a.geoProviders = providers
+	}
+	if token := os.Getenv("GEOIP_TOKEN_IPINFO"); token != "" {
+		a.geoTokenIPInfo = token
+	}
+	if dir := os.Getenv("GEOIP_MMDB_DIR"); dir != "" {
+		a.geoMMDBDir = dir
+	}
+	if ttlMin := os.Getenv("GEOIP_CACHE_TTL_MIN"); ttlMin != "" {
+		if v, err := strconv.Atoi(ttlMin); err == nil && v > 0 {
+			a.geoCacheTTLMin = v
+		}
+	}
+	if addr := os.Getenv("SUPERRAY_API_ADDR"); addr != "" {
+		a.apiAddr = addr
+	}
+	if secret := os.Getenv("SUPERRAY_API_SECRET"); secret != "" {
+		a.apiSecret = secret
+	}
+	if listen := os.Getenv("XRAY_API_LISTEN"); listen != "" {
+		a.xrayAPIListen = listen
+	}
+	if manifest := os.Getenv("RULE_PROVIDERS_CONF"); manifest != "" {
+		a.ruleManifestPath = manifest
+	}
+	if manifest := os.Getenv("PROXY_GROUPS_CONF"); manifest != "" {
+		a.groupManifestPath = manifest
+	}
+	if enabled := os.Getenv("SNIFFING_ENABLED"); enabled != "" {
+		a.sniffing.Enabled = enabled == "true" || enabled == "1"
+	}
+	if destOverride := os.Getenv("SNIFFING_DEST_OVERRIDE"); destOverride != "" {
+		a.sniffing.DestOverride = splitCSV(destOverride)
+	}
+	if metadataOnly := os.Getenv("SNIFFING_METADATA_ONLY"); metadataOnly != "" {
+		a.sniffing.MetadataOnly = metadataOnly == "true" || metadataOnly == "1"
+	}
+	if routeOnly := os.Getenv("SNIFFING_ROUTE_ONLY"); routeOnly != "" {
+		a.sniffing.RouteOnly = routeOnly == "true" || routeOnly == "1"
+	}
+	if domainsExcluded := os.Getenv("SNIFFING_DOMAINS_EXCLUDED"); domainsExcluded != "" {
+		a.sniffing.DomainsExcluded = splitCSV(domainsExcluded)
+	}
+	if enabled := os.Getenv("FAKE_DNS_ENABLED"); enabled != "" {
+		a.fakeDNSEnabled = enabled == "true" || enabled == "1"
+	}
+	if listen := os.Getenv("FAKE_DNS_LISTEN"); listen != "" {
+		a.fakeDNSCfg.Listen = listen
+	}
+	if v4 := os.Getenv("FAKE_DNS_IPV4_RANGE"); v4 != "" {
+		a.fakeDNSCfg.IPv4Range = v4
+	}
+	if v6 := os.Getenv("FAKE_DNS_IPV6_RANGE"); v6 != "" {
+		a.fakeDNSCfg.IPv6Range = v6
+	}
+	if ttlSec := os.Getenv("FAKE_DNS_TTL_SEC"); ttlSec != "" {
+		if v, err := strconv.Atoi(ttlSec); err == nil && v > 0 {
+			a.fakeDNSCfg.TTL = time.Duration(v) * time.Second
+		}
+	}
+	if direct := os.Getenv("FAKE_DNS_DIRECT_DOMAINS"); direct != "" {
+		a.fakeDNSCfg.DirectDomains = splitCSV(direct)
+	}
+	if mins := os.Getenv("SUBSCRIPTION_AUTO_REFRESH_MIN"); mins != "" {
+		if v, err := strconv.Atoi(mins); err == nil && v > 0 {
+			a.subAutoRefreshMin = v
+		}
+	}
+	if upstreams := os.Getenv("ENCRYPTED_DNS_UPSTREAMS"); upstreams != "" {
+		a.dnsUpstreams = upstreams
+	}
+	if subnet := os.Getenv("ENCRYPTED_DNS_CLIENT_SUBNET"); subnet != "" {
+		a.dnsClientSubnet = subnet
+	}
+	if path := os.Getenv("SUPERRAY_CONFIG_FILE"); path != "" {
+		a.configPath = path
+	}
+	a.loadConfigFile()
+
 	// Set geo asset directory
 	a.setupGeoPath()
+	a.setupGeoIP()
+	a.setupRuleProviders()
+	a.setupEncryptedDNS()
+}
+
+// loadConfigFile seeds the TUI-editable settings from a.configPath, so
+// edits made in a previous run (subscription URL, TUN mode, ...) survive
+// a restart. A missing file or unset a.configPath just leaves the
+// .env/env-var defaults set above in place.
+func (a *App) loadConfigFile() {
+	if a.configPath == "" {
+		return
+	}
+	cfg, err := configstore.Load(a.configPath)
+	if err != nil {
+		return
+	}
+	if cfg.SubscriptionURL != "" {
+		a.subscriptionURL = cfg.SubscriptionURL
+	}
+	if cfg.LocalPort != 0 {
+		a.localPort = cfg.LocalPort
+	}
+	if len(cfg.DirectCountries) > 0 {
+		a.directCountries = cfg.DirectCountries
+	}
+	if cfg.AccessLogPath != "" {
+		a.accessLogPath = cfg.AccessLogPath
+	}
+	if cfg.ErrorLogPath != "" {
+		a.errorLogPath = cfg.ErrorLogPath
+	}
+	a.tunMode = cfg.TunMode
 }
 
 // setupGeoPath configures the geo data path
@@ -241,6 +519,207 @@ func (a *App) setupGeoPath() {
 	superray.SetAssetDir(geoPath)
 }
 
+// setupGeoIP builds the pluggable GeoIP provider chain from GEOIP_PROVIDERS
+// (e.g. "mmdb,ipinfo,ipapi"), falling back to a local MMDB lookup plus
+// ip-api.com when unset so offline resolution still works out of the box.
+// It may be called again (e.g. from the GeoIP panel's reload action) to
+// rebuild the chain, re-opening the MMDB files; it uses the settings
+// already loaded and does not re-read .env or the environment.
+func (a *App) setupGeoIP() {
+	if geoCache == nil {
+		geoCache = geoip.LoadCache(geoip.DefaultCachePath())
+	}
+
+	names := a.geoProviders
+	if names == "" {
+		names = "mmdb,ipapi"
+	}
+
+	mmdbDir := a.geoMMDBDir
+	if mmdbDir == "" {
+		mmdbDir = a.geoPath
+	}
+
+	var providers []geoip.Provider
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "mmdb":
+			if p, err := geoip.OpenMMDBProvider(mmdbDir); err == nil {
+				providers = append(providers, p)
+			}
+		case "ipapi":
+			providers = append(providers, geoip.NewIPAPIProvider())
+		case "ipinfo":
+			providers = append(providers, geoip.NewIPInfoProvider(a.geoTokenIPInfo))
+		case "ip.sb":
+			providers = append(providers, geoip.NewIPSBProvider())
+		}
+	}
+	if len(providers) == 0 {
+		providers = append(providers, geoip.NewIPAPIProvider())
+	}
+
+	ttl := cacheTTL
+	if a.geoCacheTTLMin > 0 {
+		ttl = time.Duration(a.geoCacheTTLMin) * time.Minute
+	}
+
+	geoChain = geoip.NewChain(providers, geoCache, ttl)
+}
+
+// setupRuleProviders loads the rule-provider manifest named by
+// RULE_PROVIDERS_CONF, if any, and starts a background refresh loop for
+// each provider that declares a refresh Interval. Without a manifest,
+// buildConfig falls back to its built-in private-range/country rules.
+func (a *App) setupRuleProviders() {
+	if a.ruleCancel != nil {
+		a.ruleCancel()
+		a.ruleCancel = nil
+	}
+	if a.ruleManifestPath == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	mgr, err := ruleset.LoadManifest(ctx, a.ruleManifestPath, a.geoPath)
+	cancel()
+	if err != nil {
+		a.log(fmt.Sprintf("[red]Failed to load rule providers: %v[white]", err))
+		return
+	}
+	a.ruleManager = mgr
+
+	runCtx, runCancel := context.WithCancel(context.Background())
+	a.ruleCancel = runCancel
+	for _, p := range mgr.Providers() {
+		if p.Interval <= 0 {
+			continue
+		}
+		p := p
+		safeGo(func() { a.runRuleProviderRefresh(runCtx, mgr, p) })
+	}
+}
+
+// setupEncryptedDNS parses ENCRYPTED_DNS_UPSTREAMS ("protocol:address"
+// pairs, comma-separated) and installs the result as the global resolver
+// dnsresolver.LookupHostGlobal uses. Without it, callers fall back to
+// net.LookupHost - same as before this subsystem existed.
+func (a *App) setupEncryptedDNS() {
+	if a.dnsUpstreams == "" {
+		return
+	}
+
+	var upstreams []dnsresolver.Upstream
+	for _, raw := range splitCSV(a.dnsUpstreams) {
+		proto, address, ok := strings.Cut(raw, ":")
+		if !ok {
+			a.log(fmt.Sprintf("[red]Invalid ENCRYPTED_DNS_UPSTREAMS entry: %q[white]", raw))
+			continue
+		}
+		address = strings.TrimSpace(address)
+		switch dnsresolver.Protocol(strings.TrimSpace(proto)) {
+		case dnsresolver.ProtocolDoH, dnsresolver.ProtocolDoT, dnsresolver.ProtocolDoQ:
+			upstreams = append(upstreams, dnsresolver.Upstream{
+				Protocol: dnsresolver.Protocol(strings.TrimSpace(proto)),
+				Address:  address,
+			})
+		default:
+			a.log(fmt.Sprintf("[red]Unknown encrypted DNS protocol %q[white]", proto))
+		}
+	}
+	if len(upstreams) == 0 {
+		return
+	}
+
+	dnsresolver.SetGlobalResolver(dnsresolver.Options{
+		Upstreams:    upstreams,
+		ClientSubnet: a.dnsClientSubnet,
+	})
+}
+
+// setupProxyGroups (re)loads the proxy-group manifest named by
+// PROXY_GROUPS_CONF against the current server list and starts its probe
+// loop. Safe to call repeatedly, e.g. after a subscription refresh
+// changes which servers exist. Without a manifest, buildConfig falls back
+// to a single "proxy" outbound for the selected server.
+func (a *App) setupProxyGroups() {
+	if a.groupCancel != nil {
+		a.groupCancel()
+		a.groupCancel = nil
+	}
+	if a.groupManifestPath == "" {
+		return
+	}
+
+	a.mu.RLock()
+	servers := a.servers
+	a.mu.RUnlock()
+
+	mgr, err := proxygroup.LoadManifest(a.groupManifestPath, servers)
+	if err != nil {
+		a.log(fmt.Sprintf("[red]Failed to load proxy groups: %v[white]", err))
+		return
+	}
+	a.groupManager = mgr
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.groupCancel = cancel
+	safeGo(func() { mgr.Run(ctx) })
+}
+
+// setupFakeDNS starts the fake-IP resolver for TUN mode, if
+// FAKE_DNS_ENABLED. Safe to call repeatedly; a prior resolver is torn
+// down first. The resolver's own UDP listener exists mainly so the TUI
+// (and, eventually, a routing engine wanting the reverse domain map) can
+// inspect live allocations — Xray itself gets the same ranges directly
+// via buildConfig's dns/fakedns blocks.
+func (a *App) setupFakeDNS() {
+	a.teardownFakeDNS()
+	if !a.fakeDNSEnabled {
+		return
+	}
+
+	resolver, err := fakedns.NewResolver(a.fakeDNSCfg)
+	if err != nil {
+		a.log(fmt.Sprintf("[red]Failed to start fake-DNS: %v[white]", err))
+		return
+	}
+	a.fakeDNSResolver = resolver
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.fakeDNSCancel = cancel
+	safeGo(func() {
+		if err := resolver.Run(ctx); err != nil && ctx.Err() == nil {
+			a.log(fmt.Sprintf("[red]Fake-DNS resolver stopped: %v[white]", err))
+		}
+	})
+	a.log(fmt.Sprintf("[green]Fake-DNS resolver listening on %s[white]", a.fakeDNSCfg.Listen))
+}
+
+// teardownFakeDNS stops a running fake-DNS resolver, if any.
+func (a *App) teardownFakeDNS() {
+	if a.fakeDNSCancel != nil {
+		a.fakeDNSCancel()
+		a.fakeDNSCancel = nil
+	}
+	a.fakeDNSResolver = nil
+}
+
+func (a *App) runRuleProviderRefresh(ctx context.Context, mgr *ruleset.Manager, p *ruleset.Provider) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Refresh(ctx, nil); err != nil {
+				a.log(fmt.Sprintf("[yellow]Rule provider %q refresh failed: %v[white]", p.Name, err))
+			}
+		}
+	}
+}
+
 func (a *App) loadEnvFile(path string) error {
 	file, err := os.Open(path)
 	if err != nil {
@@ -271,6 +750,48 @@ func (a *App) loadEnvFile(path string) error {
 			fmt.Sscanf(value, "%d", &a.localPort)
 		case "SUPERRAY_GEO_PATH":
 			a.geoPath = value
+		case "GEOIP_PROVIDERS":
+			a.geoProviders = value
+		case "GEOIP_TOKEN_IPINFO":
+			a.geoTokenIPInfo = value
+		case "GEOIP_MMDB_DIR":
+			a.geoMMDBDir = value
+		case "GEOIP_CACHE_TTL_MIN":
+			if v, err := strconv.Atoi(value); err == nil && v > 0 {
+				a.geoCacheTTLMin = v
+			}
+		case "RULE_PROVIDERS_CONF":
+			a.ruleManifestPath = value
+		case "PROXY_GROUPS_CONF":
+			a.groupManifestPath = value
+		case "SNIFFING_ENABLED":
+			a.sniffing.Enabled = value == "true" || value == "1"
+		case "SNIFFING_DEST_OVERRIDE":
+			a.sniffing.DestOverride = splitCSV(value)
+		case "SNIFFING_METADATA_ONLY":
+			a.sniffing.MetadataOnly = value == "true" || value == "1"
+		case "SNIFFING_ROUTE_ONLY":
+			a.sniffing.RouteOnly = value == "true" || value == "1"
+		case "SNIFFING_DOMAINS_EXCLUDED":
+			a.sniffing.DomainsExcluded = splitCSV(value)
+		case "FAKE_DNS_ENABLED":
+			a.fakeDNSEnabled = value == "true" || value == "1"
+		case "FAKE_DNS_LISTEN":
+			a.fakeDNSCfg.Listen = value
+		case "FAKE_DNS_IPV4_RANGE":
+			a.fakeDNSCfg.IPv4Range = value
+		case "FAKE_DNS_IPV6_RANGE":
+			a.fakeDNSCfg.IPv6Range = value
+		case "FAKE_DNS_TTL_SEC":
+			if v, err := strconv.Atoi(value); err == nil && v > 0 {
+				a.fakeDNSCfg.TTL = time.Duration(v) * time.Second
+			}
+		case "FAKE_DNS_DIRECT_DOMAINS":
+			a.fakeDNSCfg.DirectDomains = splitCSV(value)
+		case "SUBSCRIPTION_AUTO_REFRESH_MIN":
+			if v, err := strconv.Atoi(value); err == nil && v > 0 {
+				a.subAutoRefreshMin = v
+			}
 		case "ACCESS_LOG":
 			a.accessLogPath = value
 		case "ERROR_LOG":
@@ -283,6 +804,12 @@ func (a *App) loadEnvFile(path string) error {
 					a.directCountries = append(a.directCountries, strings.ToLower(c))
 				}
 			}
+		case "SUPERRAY_CONFIG_FILE":
+			a.configPath = value
+		case "ENCRYPTED_DNS_UPSTREAMS":
+			a.dnsUpstreams = value
+		case "ENCRYPTED_DNS_CLIENT_SUBNET":
+			a.dnsClientSubnet = value
 		}
 	}
 
@@ -355,7 +882,7 @@ func (a *App) Run() error {
 	a.app = tview.NewApplication()
 	a.app.EnableMouse(false)
 	a.setupUI()
-	a.startUpdateLoop()
+	a.startSupervisor()
 
 	a.app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
 		a.screen = screen
@@ -377,6 +904,8 @@ func (a *App) Run() error {
 		}
 	})
 
+	a.startControlAPI()
+
 	return a.app.Run()
 }
 
@@ -478,16 +1007,123 @@ func (a *App) createServerList() {
 func (a *App) createConnList() {
 	a.connList = tview.NewTable().
 		SetBorders(false).
-		SetSelectable(false, false)
-	a.connList.SetBorder(true).SetTitle(" Connections ")
+		SetSelectable(true, false).
+		SetSelectedStyle(tcell.StyleDefault.Background(tcell.ColorDarkBlue))
+	a.connList.SetBorder(true).SetTitle(" Connections [x:Kill t:TopTalkers /:Filter] ")
+
+	a.connList.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'x', 'X':
+				safeGo(func() { a.killSelectedFlow() })
+				return nil
+			case 't':
+				a.mu.Lock()
+				a.flowTopTalkers = !a.flowTopTalkers
+				a.mu.Unlock()
+				a.app.QueueUpdateDraw(a.updateConnList)
+				return nil
+			case '/':
+				a.showFlowFilterDialog()
+				return nil
+			}
+		}
+		return event
+	})
+
 	a.updateConnList()
 }
 
+// killSelectedFlow closes the flow backing the currently selected
+// connList row through Xray's HandlerService.
+func (a *App) killSelectedFlow() {
+	a.mu.RLock()
+	tracker := a.flowTracker
+	a.mu.RUnlock()
+	if tracker == nil {
+		return
+	}
+
+	row, _ := a.connList.GetSelection()
+	id := ""
+	if cell := a.connList.GetCell(row, 0); cell != nil {
+		if ref, ok := cell.GetReference().(string); ok {
+			id = ref
+		}
+	}
+	if id == "" {
+		return
+	}
+	if err := tracker.Kill(id); err != nil {
+		a.log(fmt.Sprintf("[red]Failed to kill flow: %v[white]", err))
+	} else {
+		a.log(fmt.Sprintf("[yellow]Killed flow %s[white]", id))
+	}
+	a.app.QueueUpdateDraw(a.updateConnList)
+}
+
+// showFlowFilterDialog lets the user filter the connection list by
+// process name or host/SNI substring.
+func (a *App) showFlowFilterDialog() {
+	input := tview.NewInputField().
+		SetLabel("Filter (process or host substring): ").
+		SetFieldWidth(40)
+
+	input.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			text := input.GetText()
+			a.mu.Lock()
+			a.flowFilter = flows.Filter{Process: text, Host: text}
+			a.mu.Unlock()
+		}
+		a.pages.RemovePage("modal")
+		a.app.QueueUpdateDraw(a.updateConnList)
+	})
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(input, 3, 0, true).
+			AddItem(nil, 0, 1, false), 70, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	a.pages.AddPage("modal", modal, true, true)
+	a.app.SetFocus(input)
+}
+
+// startFlowTracker begins per-connection tracking for instanceID.
+func (a *App) startFlowTracker(instanceID string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tracker := flows.NewTracker(instanceID, time.Second, flows.LookupProcess)
+
+	a.mu.Lock()
+	a.flowTracker = tracker
+	a.flowCancel = cancel
+	a.mu.Unlock()
+
+	safeGo(func() { tracker.Run(ctx) })
+}
+
+// stopFlowTracker tears down per-connection tracking on disconnect.
+func (a *App) stopFlowTracker() {
+	a.mu.Lock()
+	cancel := a.flowCancel
+	a.flowTracker = nil
+	a.flowCancel = nil
+	a.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
 func (a *App) createHelpView() {
 	a.helpView = tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter)
-	a.helpView.SetText("[yellow]q[white]:Quit [yellow]c[white]:Connect [yellow]d[white]:Disconnect [yellow]r[white]:Load [yellow]s[white]:Sub [yellow]t[white]:Test [yellow]u[white]:TUN [yellow]f[white]:Refresh")
+	a.helpView.SetText("[yellow]q[white]:Quit [yellow]c[white]:Connect [yellow]d[white]:Disconnect [yellow]r[white]:Load [yellow]s[white]:Sub [yellow]t[white]:Test [yellow]u[white]:TUN [yellow]f[white]:Refresh [yellow]p[white]:Path [yellow]a[white]:Auto [yellow]i[white]:Info [yellow]y[white]:System [yellow]g[white]:Geo [yellow]l[white]:Rules [yellow]k[white]:Groups [yellow]n[white]:FakeDNS")
 }
 
 func (a *App) setupKeyBindings() {
@@ -530,6 +1166,30 @@ func (a *App) setupKeyBindings() {
 			case 'f', 'F':
 				a.forceRefresh()
 				return nil
+			case 'p', 'P':
+				a.showPathPanel()
+				return nil
+			case 'a', 'A':
+				a.toggleAutoFailover()
+				return nil
+			case 'i', 'I':
+				a.showServerDetailPopup()
+				return nil
+			case 'y', 'Y':
+				a.showSystemPanel()
+				return nil
+			case 'g', 'G':
+				a.showGeoIPPanel()
+				return nil
+			case 'l', 'L':
+				a.showRuleProviderPanel()
+				return nil
+			case 'k', 'K':
+				a.showProxyGroupPanel()
+				return nil
+			case 'n', 'N':
+				a.showFakeDNSPanel()
+				return nil
 			}
 		}
 		return event
@@ -559,26 +1219,97 @@ func (a *App) quit() {
 	}
 	superray.CloseAllCallbackTUNs()
 	superray.CloseAllTUNDevices()
+	a.stopFlowTracker()
 	if wasConnected && instanceID != "" {
 		superray.DestroyInstance(instanceID)
 	}
+	if a.supervisorCancel != nil {
+		a.supervisorCancel()
+	}
 
 	// Stop app
 	a.app.Stop()
 }
 
-func (a *App) startUpdateLoop() {
-	safeGo(func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			a.collectAndUpdateStats()
+// startSupervisor registers the App's background services and starts the
+// supervisor that keeps them running, restarting any that panic or
+// return with exponential backoff. Per-connection services (health
+// probes) are added later, once a server list exists; the supervisor
+// starts those immediately since it's already running.
+func (a *App) startSupervisor() {
+	a.supervisor.OnEvent = func(ev supervisor.Event) {
+		a.log(fmt.Sprintf("[darkgray][%s][white] %s", ev.Service, ev.Message))
+	}
 
+	a.supervisor.Add("stats-poller", supervisor.FuncService(a.runStatsPoller))
+	a.supervisor.Add("geoip-cache-maintainer", supervisor.FuncService(a.runGeoIPCacheMaintainer))
+	a.supervisor.Add("subscription-auto-updater", supervisor.FuncService(a.runSubscriptionAutoUpdater))
+	if a.configPath != "" {
+		a.startConfigReload()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.supervisorCancel = cancel
+	safeGo(func() { a.supervisor.Run(ctx) })
+}
+
+// runStatsPoller refreshes traffic stats and redraws once a second until
+// ctx is cancelled.
+func (a *App) runStatsPoller(ctx context.Context) error {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			a.collectAndUpdateStats()
 			a.app.QueueUpdateDraw(func() {
 				a.renderAllViews()
 			})
 		}
-	})
+	}
+}
+
+// runGeoIPCacheMaintainer persists the GeoIP lookup cache on a fixed
+// cadence, as a backstop for the per-lookup save in lookupIP: without it,
+// a long run with no fresh lookups never flushes newer entries to disk.
+func (a *App) runGeoIPCacheMaintainer(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if geoCache != nil {
+				geoCache.Save()
+			}
+		}
+	}
+}
+
+// runSubscriptionAutoUpdater reloads the subscription on a cadence set by
+// SUBSCRIPTION_AUTO_REFRESH_MIN. With no interval configured (the
+// default), it just idles until ctx is cancelled.
+func (a *App) runSubscriptionAutoUpdater(ctx context.Context) error {
+	if a.subAutoRefreshMin <= 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(time.Duration(a.subAutoRefreshMin) * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if a.subscriptionURL != "" {
+				a.loadSubscription()
+			}
+		}
+	}
 }
 
 func (a *App) collectAndUpdateStats() {
@@ -884,8 +1615,7 @@ func (a *App) updateConnList() {
 
 	a.connList.Clear()
 
-	// Header
-	headers := []string{"Protocol", "Destination", "Upload", "Download"}
+	headers := []string{"Time", "Process", "Host/SNI", "Rule", "↑", "↓", "↑/s", "↓/s"}
 	for i, h := range headers {
 		cell := tview.NewTableCell(h).
 			SetTextColor(tcell.ColorYellow).
@@ -894,34 +1624,35 @@ func (a *App) updateConnList() {
 	}
 
 	a.mu.RLock()
-	if !a.isConnected {
-		a.mu.RUnlock()
-		return
-	}
-	stats := a.lastStats
+	tracker := a.flowTracker
+	filter := a.flowFilter
+	topTalkers := a.flowTopTalkers
+	sortMode := a.flowSort
 	a.mu.RUnlock()
 
-	if stats == nil {
+	if tracker == nil {
 		return
 	}
 
-	row := 1
-	// Display inbound stats
-	for tag, inbound := range stats.Inbounds {
-		a.connList.SetCell(row, 0, tview.NewTableCell(tag).SetTextColor(tcell.ColorWhite))
-		a.connList.SetCell(row, 1, tview.NewTableCell("-").SetTextColor(tcell.ColorGray))
-		a.connList.SetCell(row, 2, tview.NewTableCell(formatBytes(inbound.Uplink)).SetTextColor(tcell.ColorGreen))
-		a.connList.SetCell(row, 3, tview.NewTableCell(formatBytes(inbound.Downlink)).SetTextColor(tcell.ColorAqua))
-		row++
-	}
-
-	// Display outbound stats
-	for tag, outbound := range stats.Outbounds {
-		a.connList.SetCell(row, 0, tview.NewTableCell(tag).SetTextColor(tcell.ColorWhite))
-		a.connList.SetCell(row, 1, tview.NewTableCell("-").SetTextColor(tcell.ColorGray))
-		a.connList.SetCell(row, 2, tview.NewTableCell(formatBytes(outbound.Uplink)).SetTextColor(tcell.ColorGreen))
-		a.connList.SetCell(row, 3, tview.NewTableCell(formatBytes(outbound.Downlink)).SetTextColor(tcell.ColorAqua))
-		row++
+	var list []*flows.Flow
+	if topTalkers {
+		list = tracker.TopTalkers(20)
+	} else {
+		list = tracker.Snapshot(filter, sortMode)
+	}
+
+	for i, f := range list {
+		row := i + 1
+		idCell := tview.NewTableCell(f.Start.Format("15:04:05")).SetTextColor(tcell.ColorWhite)
+		idCell.SetReference(f.ID)
+		a.connList.SetCell(row, 0, idCell)
+		a.connList.SetCell(row, 1, tview.NewTableCell(f.Process).SetTextColor(tcell.ColorWhite))
+		a.connList.SetCell(row, 2, tview.NewTableCell(f.Host).SetTextColor(tcell.ColorAqua))
+		a.connList.SetCell(row, 3, tview.NewTableCell(f.RuleTag+" "+f.OutboundTag).SetTextColor(tcell.ColorGray))
+		a.connList.SetCell(row, 4, tview.NewTableCell(formatBytes(f.Upload)).SetTextColor(tcell.ColorGreen))
+		a.connList.SetCell(row, 5, tview.NewTableCell(formatBytes(f.Download)).SetTextColor(tcell.ColorAqua))
+		a.connList.SetCell(row, 6, tview.NewTableCell(formatSpeed(f.UpSpeed)).SetTextColor(tcell.ColorGreen))
+		a.connList.SetCell(row, 7, tview.NewTableCell(formatSpeed(f.DownSpeed)).SetTextColor(tcell.ColorAqua))
 	}
 }
 
@@ -941,6 +1672,7 @@ func (a *App) updateServerList() {
 	servers := make([]*superray.Server, len(a.servers))
 	copy(servers, a.servers)
 	currentServer := a.currentServer
+	sched := a.healthSched
 	a.mu.RUnlock()
 
 	for i, s := range servers {
@@ -957,7 +1689,14 @@ func (a *App) updateServerList() {
 			latencyStr = " [[timeout[]]"
 		}
 
-		text := fmt.Sprintf("[[%s[]] %s%s", strings.ToUpper(s.Protocol), name, latencyStr)
+		healthBar := ""
+		if sched != nil {
+			if score := sched.Score(s); score.Samples > 0 {
+				healthBar = " " + healthScoreBar(score.Value)
+			}
+		}
+
+		text := fmt.Sprintf("[[%s[]] %s%s%s", strings.ToUpper(s.Protocol), name, latencyStr, healthBar)
 		a.serverList.AddItem(text, "", 0, nil)
 
 		if currentServer != nil && s.Address == currentServer.Address && s.Port == currentServer.Port {
@@ -1050,9 +1789,17 @@ func (a *App) connectToServer(index int) {
 		a.uploadSpeed = 0
 		a.downloadSpeed = 0
 		a.lastStats = nil
+		a.connectedAt = time.Now()
 		a.mu.Unlock()
 
 		a.log(fmt.Sprintf("[green]Connected to %s[white]", server.Name))
+		a.startFlowTracker(res.id)
+		a.mu.RLock()
+		sched := a.healthSched
+		a.mu.RUnlock()
+		if sched != nil {
+			sched.SetActive(server)
+		}
 		if a.tunMode {
 			a.log("[aqua]Mode: TUN (global proxy)[white]")
 			a.log(fmt.Sprintf("[darkgray]SOCKS5: 127.0.0.1:%d | HTTP: 127.0.0.1:%d[white]", a.localPort, a.localPort+1))
@@ -1084,6 +1831,7 @@ func (a *App) disconnectSync() {
 	a.currentServer = nil
 	a.mu.Unlock()
 
+	a.stopFlowTracker()
 	if instanceID != "" {
 		superray.DestroyInstance(instanceID)
 	}
@@ -1102,6 +1850,7 @@ func (a *App) disconnect() {
 	a.instanceID = ""
 	a.currentServer = nil
 	a.lastStats = nil
+	a.connectedAt = time.Time{}
 	a.mu.Unlock()
 
 	// Stop TUN and cleanup routes if in TUN mode
@@ -1109,6 +1858,7 @@ func (a *App) disconnect() {
 		a.stopTUN()
 	}
 
+	a.stopFlowTracker()
 	if instanceID != "" {
 		superray.DestroyInstance(instanceID)
 	}
@@ -1122,12 +1872,56 @@ func (a *App) disconnect() {
 }
 
 func (a *App) buildConfig(server *superray.Server) string {
-	// Build outbound from server
-	outbound := buildOutboundFromServer(server, "proxy")
+	// Build outbound(s). When a "main" proxy group is configured, traffic
+	// routes through its balancer (url-test/fallback/load-balance) or its
+	// currently-selected member (select) instead of a single fixed
+	// outbound for the connected server.
+	var groupOutbounds []interface{}
+	var balancers []map[string]interface{}
+	mainOutboundTag, mainBalancerTag := "proxy", ""
+
+	if a.groupManager != nil {
+		if g, ok := a.groupManager.Group("main"); ok && len(g.Members) > 0 {
+			for _, o := range proxygroup.BuildOutbounds(g, func(idx int, tag string) map[string]interface{} {
+				return buildOutboundFromServer(g.Members[idx], tag)
+			}) {
+				groupOutbounds = append(groupOutbounds, o)
+			}
+			target := proxygroup.BuildRouteTarget(g)
+			if target.Balancer != nil {
+				balancers = append(balancers, target.Balancer)
+			}
+			mainOutboundTag, mainBalancerTag = target.OutboundTag, target.BalancerTag
+		}
+	}
+	if len(groupOutbounds) == 0 {
+		groupOutbounds = append(groupOutbounds, buildOutboundFromServer(server, "proxy"))
+	}
+
+	// applyMainTarget points a routing rule at whichever of
+	// mainOutboundTag/mainBalancerTag is in effect for the "proxy" action.
+	applyMainTarget := func(rule map[string]interface{}) map[string]interface{} {
+		if mainBalancerTag != "" {
+			rule["balancerTag"] = mainBalancerTag
+		} else {
+			rule["outboundTag"] = mainOutboundTag
+		}
+		return rule
+	}
 
 	// Build routing rules
 	var rules []map[string]interface{}
 
+	// 0. Xray's own Commander API traffic, if enabled, always goes to the
+	// "api" outbound regardless of anything else below.
+	if a.xrayAPIListen != "" {
+		rules = append(rules, map[string]interface{}{
+			"type":        "field",
+			"inboundTag":  []string{"api"},
+			"outboundTag": "api",
+		})
+	}
+
 	// 1. Private and reserved addresses direct
 	rules = append(rules, map[string]interface{}{
 		"type": "field",
@@ -1161,19 +1955,38 @@ func (a *App) buildConfig(server *superray.Server) string {
 		})
 	}
 
-	// 3. Default: all other traffic through proxy
-	rules = append(rules, map[string]interface{}{
-		"type":        "field",
-		"network":     "tcp,udp",
-		"outboundTag": "proxy",
-	})
+	// 3. Rule-provider rules (DOMAIN-SUFFIX/KEYWORD, IP-CIDR, GEOSITE,
+	// GEOIP, ...), each carrying its own proxy/direct/block action.
+	if a.ruleManager != nil {
+		outboundTag := map[ruleset.RuleAction]string{
+			ruleset.ActionProxy:  "proxy",
+			ruleset.ActionDirect: "direct",
+			ruleset.ActionBlock:  "block",
+		}
+		for _, rule := range ruleset.ToXrayRules(a.ruleManager.AllRules(), outboundTag) {
+			if rule["outboundTag"] == "proxy" {
+				delete(rule, "outboundTag")
+				applyMainTarget(rule)
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	// 4. Default: all other traffic through proxy
+	rules = append(rules, applyMainTarget(map[string]interface{}{
+		"type":    "field",
+		"network": "tcp,udp",
+	}))
 
-	// Build inbounds based on mode
+	// Build inbounds based on mode. All of them share a.sniffing so
+	// domain-based routing rules work no matter which inbound traffic
+	// arrives on, not just TUN.
 	var inbounds []map[string]interface{}
+	sniffing := a.sniffing.toXray()
 
 	if a.tunMode {
 		// TUN mode: use TUN device for global proxy
-		inbounds = append(inbounds, map[string]interface{}{
+		tunIn := map[string]interface{}{
 			"tag":      "tun-in",
 			"protocol": "dokodemo-door",
 			"listen":   "127.0.0.1",
@@ -1182,15 +1995,15 @@ func (a *App) buildConfig(server *superray.Server) string {
 				"network":        "tcp,udp",
 				"followRedirect": true,
 			},
-			"sniffing": map[string]interface{}{
-				"enabled":      true,
-				"destOverride": []string{"http", "tls", "quic"},
-			},
-		})
+		}
+		if tunSniffing := a.tunSniffing(sniffing); tunSniffing != nil {
+			tunIn["sniffing"] = tunSniffing
+		}
+		inbounds = append(inbounds, tunIn)
 	}
 
 	// Always add SOCKS and HTTP inbounds
-	inbounds = append(inbounds, map[string]interface{}{
+	socksIn := map[string]interface{}{
 		"tag":      "socks-in",
 		"protocol": "socks",
 		"listen":   "127.0.0.1",
@@ -1198,13 +2011,53 @@ func (a *App) buildConfig(server *superray.Server) string {
 		"settings": map[string]interface{}{
 			"udp": true,
 		},
-	})
-	inbounds = append(inbounds, map[string]interface{}{
+	}
+	httpIn := map[string]interface{}{
 		"tag":      "http-in",
 		"protocol": "http",
 		"listen":   "127.0.0.1",
 		"port":     a.localPort + 1,
-	})
+	}
+	if sniffing != nil {
+		socksIn["sniffing"] = sniffing
+		httpIn["sniffing"] = sniffing
+	}
+	inbounds = append(inbounds, socksIn, httpIn)
+
+	var apiConfig map[string]interface{}
+	if a.xrayAPIListen != "" {
+		apiHost, apiPortStr, err := net.SplitHostPort(a.xrayAPIListen)
+		apiPort, atoiErr := strconv.Atoi(apiPortStr)
+		if err == nil && atoiErr == nil {
+			apiConfig = map[string]interface{}{
+				"tag":      "api",
+				"services": []string{"HandlerService", "StatsService", "RoutingService", "LoggerService"},
+			}
+			inbounds = append(inbounds, map[string]interface{}{
+				"tag":      "api",
+				"protocol": "dokodemo-door",
+				"listen":   apiHost,
+				"port":     apiPort,
+				"settings": map[string]interface{}{
+					"address": apiHost,
+				},
+			})
+		}
+	}
+
+	routingConfig := map[string]interface{}{
+		"domainStrategy": "IPIfNonMatch",
+		"rules":          rules,
+	}
+	if len(balancers) > 0 {
+		routingConfig["balancers"] = balancers
+	}
+
+	var dnsConfig map[string]interface{}
+	var fakednsConfig []map[string]interface{}
+	if a.tunMode && a.fakeDNSEnabled {
+		dnsConfig, fakednsConfig = a.fakeDNSCfg.XrayDNS()
+	}
 
 	config := map[string]interface{}{
 		"stats": map[string]interface{}{},
@@ -1221,9 +2074,8 @@ func (a *App) buildConfig(server *superray.Server) string {
 			"access":   a.accessLogPath,
 			"error":    a.errorLogPath,
 		},
-		"inbounds": inbounds,
-		"outbounds": []interface{}{
-			outbound,
+		"inbounds":  inbounds,
+		"outbounds": append(groupOutbounds, []interface{}{
 			map[string]interface{}{
 				"tag":      "direct",
 				"protocol": "freedom",
@@ -1232,11 +2084,15 @@ func (a *App) buildConfig(server *superray.Server) string {
 				"tag":      "block",
 				"protocol": "blackhole",
 			},
-		},
-		"routing": map[string]interface{}{
-			"domainStrategy": "IPIfNonMatch",
-			"rules":          rules,
-		},
+		}...),
+		"routing": routingConfig,
+	}
+	if dnsConfig != nil {
+		config["dns"] = dnsConfig
+		config["fakedns"] = fakednsConfig
+	}
+	if apiConfig != nil {
+		config["api"] = apiConfig
 	}
 
 	configJSON, _ := json.Marshal(config)
@@ -1266,6 +2122,9 @@ func (a *App) loadSubscription() {
 	a.servers = servers
 	a.mu.Unlock()
 
+	a.startHealthScheduler()
+	a.setupProxyGroups()
+
 	a.log(fmt.Sprintf("[green]Loaded %d servers[white]", len(servers)))
 
 	a.app.QueueUpdateDraw(func() {
@@ -1355,6 +2214,7 @@ func (a *App) showSubscriptionDialog() {
 		if key == tcell.KeyEnter {
 			a.subscriptionURL = input.GetText()
 			a.pages.RemovePage("modal")
+			a.saveConfigFile()
 			safeGo(func() { a.loadSubscription() })
 		} else if key == tcell.KeyEscape {
 			a.pages.RemovePage("modal")
@@ -1379,6 +2239,21 @@ func (a *App) log(msg string) {
 		fmt.Fprintf(a.logView, "[darkgray]%s[-] %s\n", timestamp, msg)
 		a.logView.ScrollToEnd()
 	})
+	a.broadcastLog(fmt.Sprintf("%s %s", timestamp, msg))
+}
+
+// broadcastLog fans a log line out to every active /logs subscriber
+// (used by the control API). Slow or closed subscribers are dropped
+// rather than blocking the caller.
+func (a *App) broadcastLog(line string) {
+	a.logSubsMu.Lock()
+	defer a.logSubsMu.Unlock()
+	for _, ch := range a.logSubs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
 }
 
 func (a *App) toggleTunMode() {
@@ -1406,6 +2281,7 @@ func (a *App) toggleTunMode() {
 	a.tunMode = !a.tunMode
 	mode := a.tunMode
 	a.mu.Unlock()
+	a.saveConfigFile()
 
 	if mode {
 		a.log("[green]Switched to TUN mode (global proxy)[white]")
@@ -1429,6 +2305,8 @@ func (a *App) toggleTunMode() {
 func (a *App) startTUN(instanceID string) {
 	a.log("[green]Starting System TUN device...[white]")
 
+	a.setupFakeDNS()
+
 	// Get current server address for routing
 	a.mu.RLock()
 	currentServer := a.currentServer
@@ -1464,7 +2342,13 @@ func (a *App) startTUN(instanceID string) {
 
 	// Step 3: Setup system routes
 	a.log("[yellow]Setting up routes...[white]")
-	if err := superray.SetupRoutes("tun0", currentServer.Address); err != nil {
+	if err := superray.SetupRoutes("tun0", []superray.Route{
+		// Route everything through the tunnel except the proxy server
+		// itself, which must stay on the original default gateway or
+		// Xray's own connection to it would loop back through the TUN.
+		{CIDR: "0.0.0.0/0", Via: "tun0", Install: true},
+		{CIDR: currentServer.Address + "/32", Metric: 0, Install: true},
+	}); err != nil {
 		a.log(fmt.Sprintf("[yellow]Route setup warning: %v[white]", err))
 		a.log("[darkgray]TUN device works, but routes may need manual configuration[white]")
 	} else {
@@ -1490,6 +2374,8 @@ func (a *App) stopTUN() {
 	superray.CloseAllCallbackTUNs()
 	superray.CloseAllTUNDevices()
 
+	a.teardownFakeDNS()
+
 	a.log("[yellow]TUN device closed, routes restored[white]")
 }
 
@@ -1512,6 +2398,19 @@ func formatSpeed(bytesPerSec float64) string {
 	return fmt.Sprintf("%.2f GB/s", bytesPerSec/1024/1024/1024)
 }
 
+// healthScoreBar renders a health score (roughly 0..100) as a short
+// colored bar for the server list.
+func healthScoreBar(value float64) string {
+	color := "red"
+	switch {
+	case value >= 80:
+		color = "green"
+	case value >= 50:
+		color = "yellow"
+	}
+	return fmt.Sprintf("[%s]███[-]", color)
+}
+
 func formatBytes(bytes int64) string {
 	if bytes < 1024 {
 		return fmt.Sprintf("%d B", bytes)
@@ -1710,7 +2609,7 @@ func lookupIP(ipStr string) *GeoIPInfo {
 		ipStr = ips[0].String()
 	}
 
-	// Check cache
+	// Check in-memory cache
 	ipCacheMu.RLock()
 	if cached, ok := ipCache[ipStr]; ok {
 		if time.Since(ipCacheTime[ipStr]) < cacheTTL {
@@ -1720,8 +2619,21 @@ func lookupIP(ipStr string) *GeoIPInfo {
 	}
 	ipCacheMu.RUnlock()
 
-	// Query ip-api.com (free, no API key needed, 45 requests/minute limit)
-	info := queryIPAPI(ipStr)
+	var info *GeoIPInfo
+	if geoChain != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		result, err := geoChain.Lookup(ctx, ipStr)
+		cancel()
+		if err == nil {
+			info = geoInfoFromProvider(result)
+			safeGo(func() { geoCache.Save() })
+		}
+	}
+	if info == nil {
+		// No chain configured, or every provider failed: fall back to the
+		// legacy direct ip-api.com query.
+		info = queryIPAPI(ipStr)
+	}
 
 	// Cache result
 	ipCacheMu.Lock()
@@ -1732,6 +2644,25 @@ func lookupIP(ipStr string) *GeoIPInfo {
 	return info
 }
 
+// geoInfoFromProvider converts a geoip.Info (from the pluggable provider
+// chain) into the TUI's GeoIPInfo display type.
+func geoInfoFromProvider(g *geoip.Info) *GeoIPInfo {
+	return &GeoIPInfo{
+		IP:          g.IP,
+		Country:     g.Country,
+		CountryCode: g.CountryCode,
+		Region:      g.Region,
+		RegionName:  g.RegionName,
+		City:        g.City,
+		ISP:         g.ISP,
+		Org:         g.Org,
+		AS:          g.AS,
+		ASName:      g.ASName,
+		Query:       g.Query,
+		Status:      g.Status,
+	}
+}
+
 // queryIPAPI queries ip-api.com for IP information
 func queryIPAPI(ipStr string) *GeoIPInfo {
 	info := &GeoIPInfo{
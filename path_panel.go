@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"superray-tui/pkg/traceroute"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showPathPanel runs a live traceroute to the currently selected/connected
+// server and renders hops into a modal "Path" page, adding one row per hop
+// once all of that hop's probes have been answered or have timed out.
+// Ctrl-C (or Escape) aborts the trace and closes the panel.
+func (a *App) showPathPanel() {
+	a.mu.RLock()
+	server := a.currentServer
+	if server == nil && a.selectedIndex >= 0 && a.selectedIndex < len(a.servers) {
+		server = a.servers[a.selectedIndex]
+	}
+	a.mu.RUnlock()
+
+	if server == nil {
+		a.log("[yellow]No server selected for path trace[white]")
+		return
+	}
+
+	table := tview.NewTable().SetBorders(false).SetSelectable(false, false)
+	table.SetBorder(true).SetTitle(fmt.Sprintf(" Path to %s (Ctrl-C to abort) ", maskAddress(server.Address)))
+
+	headers := []string{"Hop", "IP", "RTT1", "RTT2", "RTT3", "Loss", "ASN", "Country", "MPLS"}
+	for i, h := range headers {
+		table.SetCell(0, i, tview.NewTableCell(h).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyCtrlC {
+			cancel()
+			a.pages.RemovePage("path")
+			return nil
+		}
+		return event
+	})
+
+	a.pages.AddPage("path", table, true, true)
+	a.app.SetFocus(table)
+
+	safeGo(func() {
+		defer cancel()
+
+		events, err := traceroute.Trace(ctx, server.Address, traceroute.Options{
+			MaxHops:      30,
+			ProbesPerHop: 3,
+			Timeout:      time.Second,
+			ResolveDNS:   true,
+			Enrich: func(addr string) (asn, country string) {
+				info := lookupIP(addr)
+				if info == nil || info.Status != "success" {
+					return "", ""
+				}
+				return info.AS, info.Country
+			},
+		})
+		if err != nil {
+			a.log(fmt.Sprintf("[red]Traceroute failed: %v[white]", err))
+			return
+		}
+
+		row := 1
+		for ev := range events {
+			if ev.Err != nil {
+				a.log(fmt.Sprintf("[red]Traceroute error: %v[white]", ev.Err))
+				continue
+			}
+			hop := ev.Hop
+			r := row
+			row++
+			a.app.QueueUpdateDraw(func() {
+				renderHopRow(table, r, hop)
+			})
+		}
+	})
+}
+
+func renderHopRow(table *tview.Table, row int, hop *traceroute.Hop) {
+	table.SetCell(row, 0, tview.NewTableCell(fmt.Sprintf("%d", hop.TTL)).SetTextColor(tcell.ColorWhite))
+
+	addr := hop.Addr
+	if addr == "" {
+		addr = "*"
+	} else if hop.Hostname != "" {
+		addr = fmt.Sprintf("%s (%s)", addr, hop.Hostname)
+	}
+	table.SetCell(row, 1, tview.NewTableCell(addr).SetTextColor(tcell.ColorAqua))
+
+	for i := 0; i < 3; i++ {
+		text := "*"
+		if i < len(hop.Probes) && !hop.Probes[i].TimedOut {
+			text = hop.Probes[i].RTT.Round(time.Millisecond).String()
+		}
+		table.SetCell(row, 2+i, tview.NewTableCell(text).SetTextColor(tcell.ColorGreen))
+	}
+
+	table.SetCell(row, 5, tview.NewTableCell(fmt.Sprintf("%.0f%%", hop.Loss*100)).SetTextColor(tcell.ColorGray))
+	table.SetCell(row, 6, tview.NewTableCell(hop.ASN).SetTextColor(tcell.ColorYellow))
+	table.SetCell(row, 7, tview.NewTableCell(hop.Country).SetTextColor(tcell.ColorLime))
+
+	var mplsParts []string
+	for _, l := range hop.MPLS {
+		mplsParts = append(mplsParts, fmt.Sprintf("L%d/TC%d", l.Label, l.TC))
+	}
+	table.SetCell(row, 8, tview.NewTableCell(strings.Join(mplsParts, ",")).SetTextColor(tcell.ColorWhite))
+}
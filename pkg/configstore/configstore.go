@@ -0,0 +1,112 @@
+// Package configstore persists the App's TUI-editable settings
+// (subscription URL, local port, direct-country list, log paths, TUN
+// mode) to a YAML file so they survive a restart, and gives the running
+// App a way to pick up edits to that file - made by hand or by another
+// process - without a restart.
+package configstore
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the subset of App state that round-trips through the config
+// file, distinct from the env-var settings (GeoIP providers, rule/proxy
+// group manifests, ...) that only take effect at process start.
+type Config struct {
+	SubscriptionURL string   `yaml:"subscription_url"`
+	LocalPort       int      `yaml:"local_port"`
+	DirectCountries []string `yaml:"direct_countries"`
+	AccessLogPath   string   `yaml:"access_log"`
+	ErrorLogPath    string   `yaml:"error_log"`
+	TunMode         bool     `yaml:"tun_mode"`
+}
+
+// Load reads and parses path. A missing file isn't an error: it returns a
+// zero Config so callers can seed it from their own defaults.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("configstore: read %s: %w", path, err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("configstore: parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Save writes cfg to path as YAML, creating or truncating it.
+func Save(path string, cfg *Config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("configstore: marshal: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("configstore: write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate rejects a Config that would break the running instance if
+// applied, so a bad reload leaves the live instance untouched.
+func Validate(cfg *Config) error {
+	if cfg.LocalPort != 0 && (cfg.LocalPort < 1 || cfg.LocalPort > 65535) {
+		return fmt.Errorf("local_port %d out of range", cfg.LocalPort)
+	}
+	if cfg.SubscriptionURL != "" {
+		u, err := url.Parse(cfg.SubscriptionURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("subscription_url %q is not a valid URL", cfg.SubscriptionURL)
+		}
+	}
+	if cfg.AccessLogPath == "" || cfg.ErrorLogPath == "" {
+		return fmt.Errorf("access_log and error_log must not be empty")
+	}
+	return nil
+}
+
+// Diff reports, by YAML key, which top-level fields differ between old
+// and next, so a caller applying a reload knows exactly which in-place
+// actions (refresh subscription, rebuild running config, reopen logs,
+// ...) it needs to take.
+func Diff(old, next *Config) []string {
+	var changed []string
+	if old.SubscriptionURL != next.SubscriptionURL {
+		changed = append(changed, "subscription_url")
+	}
+	if old.LocalPort != next.LocalPort {
+		changed = append(changed, "local_port")
+	}
+	if !stringsEqual(old.DirectCountries, next.DirectCountries) {
+		changed = append(changed, "direct_countries")
+	}
+	if old.AccessLogPath != next.AccessLogPath {
+		changed = append(changed, "access_log")
+	}
+	if old.ErrorLogPath != next.ErrorLogPath {
+		changed = append(changed, "error_log")
+	}
+	if old.TunMode != next.TunMode {
+		changed = append(changed, "tun_mode")
+	}
+	return changed
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
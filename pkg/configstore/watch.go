@@ -0,0 +1,47 @@
+package configstore
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch calls onChange whenever path is written, created, or renamed into
+// place - the last covers editors and config-management tools that save
+// via a temp file plus rename - until ctx is cancelled. It watches path's
+// parent directory rather than the file itself so a reload keeps working
+// after such a rename, when the original inode is gone.
+func Watch(ctx context.Context, path string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Base(event.Name) != name {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				onChange()
+			}
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package controlapi exposes a running SuperRay instance over HTTP/WebSocket
+// using the subset of the Clash external-controller API understood by
+// dashboards like yacd and Metacubed, so the TUI can be driven headlessly
+// or from a web UI.
+package controlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ProxyInfo describes one selectable server for GET /proxies.
+type ProxyInfo struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	Now     bool   `json:"now"`
+	Delay   int    `json:"delay_ms"`
+}
+
+// ConnectionInfo describes one tracked flow for GET /connections.
+type ConnectionInfo struct {
+	ID       string    `json:"id"`
+	Upload   int64     `json:"upload"`
+	Download int64     `json:"download"`
+	Start    time.Time `json:"start"`
+	Metadata struct {
+		Network     string `json:"network"`
+		Destination string `json:"destination"`
+	} `json:"metadata"`
+}
+
+// State is implemented by the host application (the TUI's App) and gives
+// the control API read/write access to live state without duplicating it.
+type State interface {
+	Version() (superray, xray string)
+	Proxies() []ProxyInfo
+	SwitchProxy(name string) error
+	Connections() []ConnectionInfo
+	CloseConnection(id string) error
+	Traffic() (up, down int64)
+	Subscribe() (logs <-chan string, cancel func())
+}
+
+// Options configures Server.
+type Options struct {
+	Addr   string // e.g. "127.0.0.1:9090"
+	Secret string // bearer token required on every request when non-empty
+}
+
+// Server is the Clash-compatible control API HTTP server.
+type Server struct {
+	opts  Options
+	state State
+	http  *http.Server
+	up    websocket.Upgrader
+}
+
+// NewServer builds a Server bound to state. Call ListenAndServe to start
+// accepting connections.
+func NewServer(opts Options, state State) *Server {
+	s := &Server{
+		opts:  opts,
+		state: state,
+		up:    websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", s.authenticated(s.handleVersion))
+	mux.HandleFunc("/proxies", s.authenticated(s.handleProxies))
+	mux.HandleFunc("/proxies/", s.authenticated(s.handleSwitchProxy))
+	mux.HandleFunc("/connections", s.authenticated(s.handleConnections))
+	mux.HandleFunc("/connections/", s.authenticated(s.handleCloseConnection))
+	mux.HandleFunc("/traffic", s.authenticated(s.handleTraffic))
+	mux.HandleFunc("/logs", s.authenticated(s.handleLogs))
+
+	s.http = &http.Server{Addr: opts.Addr, Handler: mux}
+	return s
+}
+
+// ListenAndServe starts the server; it blocks until the server stops or
+// errors, mirroring net/http.Server.
+func (s *Server) ListenAndServe() error {
+	return s.http.ListenAndServe()
+}
+
+// Shutdown gracefully stops the server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.Secret != "" {
+			token := r.Header.Get("Authorization")
+			token = strings.TrimPrefix(token, "Bearer ")
+			if token == "" {
+				token = r.URL.Query().Get("token")
+			}
+			if token != s.opts.Secret {
+				http.Error(w, `{"message":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	superrayVer, xrayVer := s.state.Version()
+	writeJSON(w, map[string]string{"version": superrayVer, "xray": xrayVer})
+}
+
+func (s *Server) handleProxies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"proxies": s.state.Proxies()})
+}
+
+func (s *Server) handleSwitchProxy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/proxies/")
+	if name == "" {
+		http.Error(w, `{"message":"missing proxy name"}`, http.StatusBadRequest)
+		return
+	}
+	if err := s.state.SwitchProxy(name); err != nil {
+		http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, map[string]interface{}{"connections": s.state.Connections()})
+	case http.MethodDelete:
+		// Clash also allows DELETE /connections to close everything; not
+		// supported here since SuperRay tracks a single active server.
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleCloseConnection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/connections/")
+	if err := s.state.CloseConnection(id); err != nil {
+		http.Error(w, `{"message":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleTraffic(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.up.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		up, down := s.state.Traffic()
+		if err := conn.WriteJSON(map[string]int64{"up": up, "down": down}); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.up.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	logs, cancel := s.state.Subscribe()
+	defer cancel()
+
+	for line := range logs {
+		if err := conn.WriteJSON(map[string]string{"type": "info", "payload": line}); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package dnsresolver
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize bounds a Resolver's LRU cache when Options.CacheSize
+// is unset.
+const DefaultCacheSize = 1024
+
+type cacheEntry struct {
+	host    string
+	ips     []string
+	expires time.Time
+}
+
+// lruCache is a fixed-capacity, TTL-aware LRU cache keyed by hostname.
+// An entry past its TTL is treated as a miss but isn't removed: it stays
+// until a fresh answer for the same host replaces it or capacity forces
+// it out, same trade-off as most stub resolver caches.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+func newLRUCache(capacity int) *lruCache {
+	if capacity <= 0 {
+		capacity = DefaultCacheSize
+	}
+	return &lruCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+func (c *lruCache) get(host string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[host]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.ips, true
+}
+
+func (c *lruCache) set(host string, ips []string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[host]; ok {
+		elem.Value.(*cacheEntry).ips = ips
+		elem.Value.(*cacheEntry).expires = time.Now().Add(ttl)
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	elem := c.ll.PushFront(&cacheEntry{host: host, ips: ips, expires: time.Now().Add(ttl)})
+	c.items[host] = elem
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheEntry).host)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Package dnsresolver is an encrypted-DNS client: DoH (RFC 8484 wire
+// format), DoT, and DoQ upstreams, an LRU cache honoring answer TTLs,
+// EDNS0 client-subnet, and parallel A/AAAA lookups (AAAA started first,
+// A after a short delay) whose answers are combined with the AAAA
+// addresses listed first. It exists because superray.LookupHost only offers
+// the system resolver, which most TUN-mode deployments want to avoid
+// leaking plaintext queries through.
+package dnsresolver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Protocol names one of the encrypted transports a Upstream speaks.
+type Protocol string
+
+const (
+	ProtocolDoH Protocol = "doh"
+	ProtocolDoT Protocol = "dot"
+	ProtocolDoQ Protocol = "doq"
+)
+
+// Upstream is one encrypted DNS server. Address is a full "https://"
+// URL for DoH, or a "host:port" for DoT/DoQ.
+type Upstream struct {
+	Protocol Protocol
+	Address  string
+}
+
+// Route sends queries for Hosts (exact match) or Suffixes (domain and
+// anything under it) to Upstreams instead of Options.Upstreams - e.g.
+// routing an internal zone to a split-horizon resolver while everything
+// else goes to a public DoH provider.
+type Route struct {
+	Hosts     []string
+	Suffixes  []string
+	Upstreams []Upstream
+}
+
+// Options configures a Resolver.
+type Options struct {
+	// Upstreams is the default upstream list, tried in order until one
+	// answers; Routes override it per domain.
+	Upstreams []Upstream
+	Routes    []Route
+
+	// ClientSubnet, if set, is sent as an EDNS0 CLIENT-SUBNET option
+	// (RFC 7871) on every query.
+	ClientSubnet string
+
+	// CacheSize bounds the LRU cache; DefaultCacheSize if zero.
+	CacheSize int
+	// Timeout bounds a single upstream exchange; DefaultTimeout if zero.
+	Timeout time.Duration
+	// EyeballsDelay is how long the A query is held back after the AAAA
+	// query starts; DefaultEyeballsDelay if zero.
+	EyeballsDelay time.Duration
+}
+
+// DefaultEyeballsDelay is the A-query head start given to AAAA, loosely
+// following the "resolution delay" RFC 8305 recommends for Happy
+// Eyeballs clients doing their own A/AAAA lookups.
+const DefaultEyeballsDelay = 50 * time.Millisecond
+
+// Resolver looks up hostnames over encrypted DNS per its Options.
+type Resolver struct {
+	opts   Options
+	cache  *lruCache
+	subnet *net.IPNet
+}
+
+// New builds a Resolver from opts. opts.ClientSubnet, if invalid, is
+// silently ignored rather than rejected, since a malformed subnet
+// shouldn't be fatal to an otherwise-working resolver.
+func New(opts Options) *Resolver {
+	r := &Resolver{
+		opts:  opts,
+		cache: newLRUCache(opts.CacheSize),
+	}
+	if opts.ClientSubnet != "" {
+		if _, subnet, err := net.ParseCIDR(opts.ClientSubnet); err == nil {
+			r.subnet = subnet
+		}
+	}
+	return r
+}
+
+func (r *Resolver) timeout() time.Duration {
+	if r.opts.Timeout > 0 {
+		return r.opts.Timeout
+	}
+	return DefaultTimeout
+}
+
+func (r *Resolver) eyeballsDelay() time.Duration {
+	if r.opts.EyeballsDelay > 0 {
+		return r.opts.EyeballsDelay
+	}
+	return DefaultEyeballsDelay
+}
+
+// upstreamsFor returns the Route.Upstreams of the first Route listing
+// host in Hosts, else of the first Route (in Routes order, not by suffix
+// length) with a matching suffix, or r.opts.Upstreams if none match.
+func (r *Resolver) upstreamsFor(host string) []Upstream {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, route := range r.opts.Routes {
+		for _, h := range route.Hosts {
+			if host == strings.ToLower(h) {
+				return route.Upstreams
+			}
+		}
+	}
+	for _, route := range r.opts.Routes {
+		for _, suffix := range route.Suffixes {
+			suffix = strings.ToLower(strings.TrimSuffix(suffix, "."))
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return route.Upstreams
+			}
+		}
+	}
+	return r.opts.Upstreams
+}
+
+// LookupHost resolves host to its IPv4 and IPv6 addresses, querying A
+// and AAAA in parallel (AAAA first, A after eyeballsDelay), waiting for
+// both and returning their addresses together, AAAA ones first. Answers
+// are served from cache until their TTL expires.
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	host = strings.TrimSuffix(host, ".")
+	if ips, ok := r.cache.get(host); ok {
+		return ips, nil
+	}
+
+	upstreams := r.upstreamsFor(host)
+	if len(upstreams) == 0 {
+		return nil, fmt.Errorf("dnsresolver: no upstream configured for %q", host)
+	}
+
+	var wg sync.WaitGroup
+	var aIPs, aaaaIPs []string
+	var aTTL, aaaaTTL time.Duration
+	var aErr, aaaaErr error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		aaaaIPs, aaaaTTL, aaaaErr = r.query(ctx, upstreams, host, typeAAAA)
+	}()
+	go func() {
+		defer wg.Done()
+		select {
+		case <-time.After(r.eyeballsDelay()):
+		case <-ctx.Done():
+			aErr = ctx.Err()
+			return
+		}
+		aIPs, aTTL, aErr = r.query(ctx, upstreams, host, typeA)
+	}()
+	wg.Wait()
+
+	ips := append(append([]string{}, aaaaIPs...), aIPs...)
+	if len(ips) == 0 {
+		switch {
+		case aaaaErr != nil:
+			return nil, aaaaErr
+		case aErr != nil:
+			return nil, aErr
+		default:
+			return nil, fmt.Errorf("dnsresolver: no such host %q", host)
+		}
+	}
+
+	ttl := aaaaTTL
+	if ttl == 0 || (aTTL > 0 && aTTL < ttl) {
+		ttl = aTTL
+	}
+	r.cache.set(host, ips, ttl)
+	return ips, nil
+}
+
+// query tries each upstream in order for a single qtype, returning the
+// first one that answers without error. Each upstream exchange gets its
+// own timeout.
+func (r *Resolver) query(ctx context.Context, upstreams []Upstream, host string, qtype uint16) ([]string, time.Duration, error) {
+	id := randomID()
+	msg := buildQuery(id, host, qtype, r.subnet)
+
+	var lastErr error
+	for _, u := range upstreams {
+		qctx, cancel := context.WithTimeout(ctx, r.timeout())
+		raw, err := exchange(qctx, u, msg)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		ips, ttl, err := parseAnswer(raw, qtype)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return ips, ttl, nil
+	}
+	return nil, 0, lastErr
+}
+
+func randomID() uint16 {
+	var b [2]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b[:])
+}
+
+var (
+	globalMu       sync.RWMutex
+	globalResolver *Resolver
+)
+
+// SetGlobalResolver installs opts as the resolver LookupHostGlobal uses,
+// so callers that want every lookup (health probes, subscription
+// fetches, ...) to share one encrypted-DNS configuration don't each need
+// to build their own Options.
+func SetGlobalResolver(opts Options) {
+	r := New(opts)
+	globalMu.Lock()
+	globalResolver = r
+	globalMu.Unlock()
+}
+
+// LookupHostGlobal resolves host using the Resolver installed by
+// SetGlobalResolver, falling back to net.LookupHost if none has been
+// set.
+func LookupHostGlobal(ctx context.Context, host string) ([]string, error) {
+	globalMu.RLock()
+	r := globalResolver
+	globalMu.RUnlock()
+	if r == nil {
+		return net.LookupHost(host)
+	}
+	return r.LookupHost(ctx, host)
+}
+
+// resolverCache reuses one Resolver (and its LRU cache) per distinct
+// Options value across LookupHostSecure calls, instead of discarding the
+// cache built up by the last call with the same opts.
+var (
+	resolverCacheMu sync.Mutex
+	resolverCache   = map[string]*Resolver{}
+)
+
+// LookupHostSecure resolves host via a Resolver built from opts,
+// reusing one across calls with equivalent opts.
+func LookupHostSecure(host string, opts Options) ([]string, error) {
+	return resolverForOpts(opts).LookupHost(context.Background(), host)
+}
+
+func resolverForOpts(opts Options) *Resolver {
+	key := optionsKey(opts)
+
+	resolverCacheMu.Lock()
+	defer resolverCacheMu.Unlock()
+	if r, ok := resolverCache[key]; ok {
+		return r
+	}
+	r := New(opts)
+	resolverCache[key] = r
+	return r
+}
+
+// optionsKey renders opts as a stable map key. It only needs to
+// distinguish configurations that should get separate caches, not to be
+// a canonical encoding.
+func optionsKey(opts Options) string {
+	var b strings.Builder
+	for _, u := range opts.Upstreams {
+		fmt.Fprintf(&b, "%s|%s;", u.Protocol, u.Address)
+	}
+	b.WriteString("/")
+	for _, route := range opts.Routes {
+		fmt.Fprintf(&b, "%v>%v|", append(append([]string{}, route.Hosts...), route.Suffixes...), route.Upstreams)
+	}
+	fmt.Fprintf(&b, "/%s/%d/%d/%d", opts.ClientSubnet, opts.CacheSize, opts.Timeout, opts.EyeballsDelay)
+	return b.String()
+}
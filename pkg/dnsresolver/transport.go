@@ -0,0 +1,121 @@
+package dnsresolver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// DefaultTimeout bounds a single upstream exchange when Options.Timeout
+// is unset.
+const DefaultTimeout = 5 * time.Second
+
+var dohClient = &http.Client{Timeout: DefaultTimeout}
+
+// exchange sends query to u and returns its raw DNS wire-format response.
+func exchange(ctx context.Context, u Upstream, query []byte) ([]byte, error) {
+	switch u.Protocol {
+	case ProtocolDoH:
+		return dohExchange(ctx, u.Address, query)
+	case ProtocolDoT:
+		return dotExchange(ctx, u.Address, query)
+	case ProtocolDoQ:
+		return doqExchange(ctx, u.Address, query)
+	default:
+		return nil, fmt.Errorf("dnsresolver: unknown protocol %q", u.Protocol)
+	}
+}
+
+// dohExchange implements the RFC 8484 wire-format POST: the raw DNS
+// message as the request body, answered with one in kind.
+func dohExchange(ctx context.Context, address string, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, address, bytes.NewReader(query))
+	if err != nil {
+		return nil, fmt.Errorf("dnsresolver: doh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := dohClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("dnsresolver: doh %s: %w", address, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("dnsresolver: doh %s: status %d", address, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+}
+
+// dotExchange implements classic length-prefixed DNS (RFC 1035 §4.2.2)
+// over a TLS connection, as DNS-over-TLS (RFC 7858) specifies.
+func dotExchange(ctx context.Context, address string, query []byte) ([]byte, error) {
+	dialer := tls.Dialer{Config: &tls.Config{MinVersion: tls.VersionTLS12}}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("dnsresolver: dot dial %s: %w", address, err)
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+
+	if err := writeTCPMessage(conn, query); err != nil {
+		return nil, fmt.Errorf("dnsresolver: dot %s: %w", address, err)
+	}
+	return readTCPMessage(conn)
+}
+
+// doqExchange implements DNS-over-QUIC (RFC 9250 §4.2): one query per
+// bidirectional stream, length-prefixed the same way as DoT/classic TCP,
+// with the send side closed to signal the query is complete.
+func doqExchange(ctx context.Context, address string, query []byte) ([]byte, error) {
+	tlsConf := &tls.Config{MinVersion: tls.VersionTLS13, NextProtos: []string{"doq"}}
+	conn, err := quic.DialAddr(ctx, address, tlsConf, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dnsresolver: doq dial %s: %w", address, err)
+	}
+	defer conn.CloseWithError(0, "")
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dnsresolver: doq %s: open stream: %w", address, err)
+	}
+	defer stream.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(dl)
+	}
+
+	if err := writeTCPMessage(stream, query); err != nil {
+		return nil, fmt.Errorf("dnsresolver: doq %s: %w", address, err)
+	}
+	stream.Close() // half-close: no more queries on this stream
+	return readTCPMessage(stream)
+}
+
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	prefixed := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(prefixed, uint16(len(msg)))
+	copy(prefixed[2:], msg)
+	_, err := w.Write(prefixed)
+	return err
+}
+
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var lenBuf [2]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(lenBuf[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
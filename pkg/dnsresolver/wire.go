@@ -0,0 +1,201 @@
+package dnsresolver
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	typeA     = 1
+	typeAAAA  = 28
+	typeOPT   = 41
+	classIN   = 1
+	optCode   = 8 // EDNS0 CLIENT-SUBNET, RFC 7871
+	family4   = 1
+	family6   = 2
+)
+
+// buildQuery encodes a single-question DNS wire-format query for
+// name/qtype with the recursion-desired bit set, plus an EDNS0 OPT
+// record carrying a client-subnet option when subnet is non-empty.
+func buildQuery(id uint16, name string, qtype uint16, subnet *net.IPNet) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:2], id)
+	msg[2] = 0x01 // RD
+	binary.BigEndian.PutUint16(msg[4:6], 1) // QDCOUNT
+
+	msg = append(msg, encodeName(name)...)
+	msg = append(msg, 0, 0)
+	binary.BigEndian.PutUint16(msg[len(msg)-2:], qtype)
+	msg = append(msg, 0, 0)
+	binary.BigEndian.PutUint16(msg[len(msg)-2:], classIN)
+
+	if subnet == nil {
+		return msg
+	}
+
+	opt := encodeClientSubnetOPT(subnet)
+	binary.BigEndian.PutUint16(msg[10:12], 1) // ARCOUNT
+	return append(msg, opt...)
+}
+
+// encodeName renders a dotted hostname as length-prefixed DNS labels
+// terminated by a zero-length root label.
+func encodeName(name string) []byte {
+	name = strings.TrimSuffix(name, ".")
+	var out []byte
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			out = append(out, byte(len(label)))
+			out = append(out, label...)
+		}
+	}
+	return append(out, 0)
+}
+
+// encodeClientSubnetOPT builds a pseudo-RR OPT record with one EDNS0
+// CLIENT-SUBNET option (RFC 7871), so upstreams that vary answers by
+// requester geography see subnet instead of this process's own address.
+func encodeClientSubnetOPT(subnet *net.IPNet) []byte {
+	ones, _ := subnet.Mask.Size()
+	ip4 := subnet.IP.To4()
+	family := uint16(family6)
+	addr := subnet.IP.To16()
+	if ip4 != nil {
+		family = family4
+		addr = ip4
+	}
+	addrBytes := (ones + 7) / 8
+	if addrBytes > len(addr) {
+		addrBytes = len(addr)
+	}
+	addr = addr[:addrBytes]
+
+	optData := make([]byte, 4+len(addr))
+	binary.BigEndian.PutUint16(optData[0:2], family)
+	optData[2] = byte(ones)
+	optData[3] = 0 // SCOPE PREFIX-LENGTH, unset in a query
+	copy(optData[4:], addr)
+
+	rdata := make([]byte, 4+len(optData))
+	binary.BigEndian.PutUint16(rdata[0:2], optCode)
+	binary.BigEndian.PutUint16(rdata[2:4], uint16(len(optData)))
+	copy(rdata[4:], optData)
+
+	rr := make([]byte, 0, 11+len(rdata))
+	rr = append(rr, 0)                          // NAME: root
+	rr = append(rr, byte(typeOPT>>8), byte(typeOPT))
+	rr = append(rr, 0x10, 0x00) // CLASS: requestor's UDP payload size (4096)
+	rr = append(rr, 0, 0, 0, 0) // TTL: extended RCODE/flags, all zero
+	rr = append(rr, byte(len(rdata)>>8), byte(len(rdata)))
+	rr = append(rr, rdata...)
+	return rr
+}
+
+// parseAnswer extracts the qtype-matching addresses and the minimum TTL
+// among them from a raw DNS response. Answers of other types (e.g. CNAME
+// chains) are skipped rather than followed, since every upstream here is
+// expected to resolve CNAMEs server-side before answering.
+func parseAnswer(raw []byte, qtype uint16) (ips []string, ttl time.Duration, err error) {
+	if len(raw) < 12 {
+		return nil, 0, errors.New("dnsresolver: response too short")
+	}
+	if rcode := raw[3] & 0x0F; rcode != 0 {
+		return nil, 0, nil
+	}
+
+	qdcount := int(binary.BigEndian.Uint16(raw[4:6]))
+	ancount := int(binary.BigEndian.Uint16(raw[6:8]))
+
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		_, next, err := readName(raw, off)
+		if err != nil {
+			return nil, 0, err
+		}
+		off = next + 4 // QTYPE + QCLASS
+	}
+
+	var minTTL uint32 = 0
+	for i := 0; i < ancount; i++ {
+		if off >= len(raw) {
+			break
+		}
+		_, next, err := readName(raw, off)
+		if err != nil {
+			return nil, 0, err
+		}
+		if next+10 > len(raw) {
+			return nil, 0, errors.New("dnsresolver: truncated answer record")
+		}
+		rtype := binary.BigEndian.Uint16(raw[next : next+2])
+		rttl := binary.BigEndian.Uint32(raw[next+4 : next+8])
+		rdlen := int(binary.BigEndian.Uint16(raw[next+8 : next+10]))
+		rdata := next + 10
+		if rdata+rdlen > len(raw) {
+			return nil, 0, errors.New("dnsresolver: truncated record data")
+		}
+
+		if rtype == qtype {
+			switch qtype {
+			case typeA:
+				if rdlen == 4 {
+					ips = append(ips, net.IP(raw[rdata:rdata+4]).String())
+				}
+			case typeAAAA:
+				if rdlen == 16 {
+					ips = append(ips, net.IP(raw[rdata:rdata+16]).String())
+				}
+			}
+			if minTTL == 0 || rttl < minTTL {
+				minTTL = rttl
+			}
+		}
+		off = rdata + rdlen
+	}
+	return ips, time.Duration(minTTL) * time.Second, nil
+}
+
+// readName decodes a (possibly compressed) DNS name starting at off,
+// returning the offset in msg just past it, before following any
+// compression pointer.
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	afterName := -1
+	pos := off
+	hops := 0
+
+	for {
+		if pos >= len(msg) {
+			return "", 0, errors.New("dnsresolver: name runs past message end")
+		}
+		length := int(msg[pos])
+		switch {
+		case length == 0:
+			if afterName == -1 {
+				afterName = pos + 1
+			}
+			return strings.Join(labels, "."), afterName, nil
+		case length&0xC0 == 0xC0:
+			if pos+1 >= len(msg) {
+				return "", 0, errors.New("dnsresolver: truncated compression pointer")
+			}
+			if afterName == -1 {
+				afterName = pos + 2
+			}
+			if hops++; hops > 64 {
+				return "", 0, errors.New("dnsresolver: compression pointer loop")
+			}
+			pos = int(binary.BigEndian.Uint16(msg[pos:pos+2]) & 0x3FFF)
+		default:
+			if pos+1+length > len(msg) {
+				return "", 0, errors.New("dnsresolver: label runs past message end")
+			}
+			labels = append(labels, string(msg[pos+1:pos+1+length]))
+			pos += 1 + length
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package dnsresolver
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestEncodeNameRootLabel(t *testing.T) {
+	got := encodeName("")
+	if len(got) != 1 || got[0] != 0 {
+		t.Fatalf("encodeName(%q) = %v, want a single root label", "", got)
+	}
+}
+
+func TestBuildQueryRoundTripsThroughParseAnswer(t *testing.T) {
+	msg := buildQuery(0x1234, "example.com.", typeA, nil)
+
+	// Reuse the question section in a synthetic response with one A
+	// answer, mirroring what a real upstream would send back.
+	resp := append([]byte(nil), msg...)
+	resp[2] |= 0x80 // QR: response
+	resp = append(resp,
+		0xC0, 0x0C, // NAME: pointer to the question
+		byte(typeA>>8), byte(typeA),
+		0, classIN,
+		0, 0, 0, 60, // TTL
+		0, 4, // RDLENGTH
+		93, 184, 216, 34, // RDATA: 93.184.216.34
+	)
+	resp[7] = 1 // ANCOUNT
+
+	ips, ttl, err := parseAnswer(resp, typeA)
+	if err != nil {
+		t.Fatalf("parseAnswer: %v", err)
+	}
+	if len(ips) != 1 || ips[0] != "93.184.216.34" {
+		t.Fatalf("ips = %v, want [93.184.216.34]", ips)
+	}
+	if ttl != 60*time.Second {
+		t.Fatalf("ttl = %v, want 60s", ttl)
+	}
+}
+
+func TestBuildQuerySetsARCOUNTForClientSubnet(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("203.0.113.0/24")
+	msg := buildQuery(1, "example.com", typeA, subnet)
+
+	arcount := int(msg[10])<<8 | int(msg[11])
+	if arcount != 1 {
+		t.Fatalf("ARCOUNT = %d, want 1 with a client-subnet option", arcount)
+	}
+}
+
+func TestParseAnswerNonZeroRcodeReturnsNoIPs(t *testing.T) {
+	msg := buildQuery(1, "example.com.", typeA, nil)
+	resp := append([]byte(nil), msg...)
+	resp[2] |= 0x80
+	resp[3] = 0x03 // RCODE: NXDOMAIN
+
+	ips, ttl, err := parseAnswer(resp, typeA)
+	if err != nil {
+		t.Fatalf("parseAnswer: %v", err)
+	}
+	if ips != nil || ttl != 0 {
+		t.Fatalf("ips=%v ttl=%v, want nil/0 on NXDOMAIN", ips, ttl)
+	}
+}
+
+func TestReadNameFollowsCompressionPointer(t *testing.T) {
+	msg := append([]byte{}, make([]byte, 12)...)
+	msg = append(msg, encodeName("example.com")...) // at offset 12
+	pointerOff := len(msg)
+	msg = append(msg, 0xC0, 12) // pointer back to offset 12
+
+	name, next, err := readName(msg, pointerOff)
+	if err != nil {
+		t.Fatalf("readName: %v", err)
+	}
+	if name != "example.com" {
+		t.Fatalf("name = %q, want example.com", name)
+	}
+	if next != pointerOff+2 {
+		t.Fatalf("next = %d, want %d (past the 2-byte pointer)", next, pointerOff+2)
+	}
+}
+
+func TestReadNameDetectsCompressionLoop(t *testing.T) {
+	msg := make([]byte, 14)
+	msg[12], msg[13] = 0xC0, 12 // pointer to itself
+
+	if _, _, err := readName(msg, 12); err == nil {
+		t.Fatal("readName: want error on self-referential compression pointer")
+	}
+}
@@ -0,0 +1,128 @@
+package fakedns
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	typeA    = 1
+	typeAAAA = 28
+	classIN  = 1
+)
+
+// query is the decoded question of an incoming DNS message. Only the
+// first question is considered; additional/authority records and EDNS0
+// are ignored since the fake-IP resolver only ever needs to answer one
+// A/AAAA lookup per packet.
+type query struct {
+	name  string
+	qtype uint16
+}
+
+// parseQuery decodes the header and question section of msg.
+func parseQuery(msg []byte) (query, error) {
+	if len(msg) < 12 {
+		return query{}, errors.New("fakedns: message too short")
+	}
+	if binary.BigEndian.Uint16(msg[4:6]) == 0 {
+		return query{}, errors.New("fakedns: no question")
+	}
+
+	name, off, err := readName(msg, 12)
+	if err != nil {
+		return query{}, err
+	}
+	if off+4 > len(msg) {
+		return query{}, errors.New("fakedns: truncated question")
+	}
+	qtype := binary.BigEndian.Uint16(msg[off : off+2])
+	return query{name: name, qtype: qtype}, nil
+}
+
+// readName decodes a (possibly compressed) DNS name starting at off,
+// returning the dotted name and the offset in msg just past the
+// uncompressed portion of it (i.e. before following any pointer).
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	afterName := -1
+	pos := off
+
+	for {
+		if pos >= len(msg) {
+			return "", 0, errors.New("fakedns: name runs past message end")
+		}
+		length := int(msg[pos])
+		switch {
+		case length == 0:
+			if afterName == -1 {
+				afterName = pos + 1
+			}
+			return strings.Join(labels, "."), afterName, nil
+		case length&0xC0 == 0xC0:
+			if pos+1 >= len(msg) {
+				return "", 0, errors.New("fakedns: truncated compression pointer")
+			}
+			if afterName == -1 {
+				afterName = pos + 2
+			}
+			pos = int(binary.BigEndian.Uint16(msg[pos:pos+2]) & 0x3FFF)
+		default:
+			if pos+1+length > len(msg) {
+				return "", 0, errors.New("fakedns: label runs past message end")
+			}
+			labels = append(labels, string(msg[pos+1:pos+1+length]))
+			pos += 1 + length
+		}
+	}
+}
+
+// buildResponse turns rawQuery into a reply: it reuses the original
+// header/question bytes (so the ID and question section round-trip
+// exactly) and appends one A/AAAA record per ip. RCODE is NXDOMAIN when
+// nxdomain is set (the name itself is unknown); an empty ips with
+// nxdomain false yields NOERROR/NODATA (the name exists but has no
+// record of the queried type, e.g. an AAAA query with no IPv6 pool
+// configured) so callers don't negatively-cache the whole name.
+func buildResponse(rawQuery []byte, q query, ips []net.IP, nxdomain bool, ttl time.Duration) []byte {
+	resp := make([]byte, len(rawQuery), len(rawQuery)+len(ips)*28)
+	copy(resp, rawQuery)
+
+	resp[2] |= 0x80 // QR: response
+	resp[3] = 0x80  // RA: recursion available; clear Z/RCODE
+	if nxdomain {
+		resp[3] |= 0x03 // RCODE: NXDOMAIN
+	}
+	binary.BigEndian.PutUint16(resp[8:10], 0)  // NSCOUNT
+	binary.BigEndian.PutUint16(resp[10:12], 0) // ARCOUNT
+
+	ttlSecs := uint32(ttl.Seconds())
+	var ancount uint16
+	for _, ip := range ips {
+		var rdata []byte
+		var rtype uint16
+		switch {
+		case q.qtype == typeA && ip.To4() != nil:
+			rdata, rtype = ip.To4(), typeA
+		case q.qtype == typeAAAA && ip.To4() == nil:
+			rdata, rtype = ip.To16(), typeAAAA
+		default:
+			continue
+		}
+
+		resp = append(resp, 0xC0, 0x0C) // name: pointer to the question at offset 12
+		rec := make([]byte, 10)
+		binary.BigEndian.PutUint16(rec[0:2], rtype)
+		binary.BigEndian.PutUint16(rec[2:4], classIN)
+		binary.BigEndian.PutUint32(rec[4:8], ttlSecs)
+		binary.BigEndian.PutUint16(rec[8:10], uint16(len(rdata)))
+		resp = append(resp, rec...)
+		resp = append(resp, rdata...)
+		ancount++
+	}
+	binary.BigEndian.PutUint16(resp[6:8], ancount) // ANCOUNT
+	return resp
+}
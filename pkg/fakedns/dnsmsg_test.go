@@ -0,0 +1,139 @@
+package fakedns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// rawQuery builds a minimal single-question DNS query for name/qtype,
+// mirroring what a real client would send.
+func rawQuery(id uint16, name string, qtype uint16) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:2], id)
+	binary.BigEndian.PutUint16(msg[4:6], 1) // QDCOUNT
+	for _, label := range splitName(name) {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = append(msg, 0, 0)
+	binary.BigEndian.PutUint16(msg[len(msg)-2:], qtype)
+	msg = append(msg, 0, 0)
+	binary.BigEndian.PutUint16(msg[len(msg)-2:], classIN)
+	return msg
+}
+
+func splitName(name string) []string {
+	var labels []string
+	start := 0
+	for i := 0; i < len(name); i++ {
+		if name[i] == '.' {
+			labels = append(labels, name[start:i])
+			start = i + 1
+		}
+	}
+	labels = append(labels, name[start:])
+	return labels
+}
+
+func TestParseQueryDecodesNameAndType(t *testing.T) {
+	msg := rawQuery(1, "example.com", typeAAAA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery: %v", err)
+	}
+	if q.name != "example.com" || q.qtype != typeAAAA {
+		t.Fatalf("parseQuery = %+v, want name=example.com qtype=AAAA", q)
+	}
+}
+
+func TestParseQueryRejectsTruncatedMessage(t *testing.T) {
+	if _, err := parseQuery([]byte{0, 1, 2}); err == nil {
+		t.Fatal("parseQuery: want error on a message shorter than the header")
+	}
+}
+
+func TestBuildResponseSetsNXDOMAINOnlyForUnknownName(t *testing.T) {
+	msg := rawQuery(1, "example.com", typeA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery: %v", err)
+	}
+
+	resp := buildResponse(msg, q, nil, true, time.Minute)
+	if rcode := resp[3] & 0x0F; rcode != 0x03 {
+		t.Fatalf("RCODE = %d, want NXDOMAIN (3)", rcode)
+	}
+}
+
+func TestBuildResponseNoRecordsWithoutNXDOMAINIsNODATA(t *testing.T) {
+	msg := rawQuery(1, "example.com", typeAAAA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery: %v", err)
+	}
+
+	// No IPv6 pool configured: the name exists but AAAA has no answer.
+	resp := buildResponse(msg, q, nil, false, time.Minute)
+	if rcode := resp[3] & 0x0F; rcode != 0 {
+		t.Fatalf("RCODE = %d, want NOERROR (0) for NODATA", rcode)
+	}
+	ancount := binary.BigEndian.Uint16(resp[6:8])
+	if ancount != 0 {
+		t.Fatalf("ANCOUNT = %d, want 0", ancount)
+	}
+}
+
+func TestBuildResponseEncodesOneRecordPerIP(t *testing.T) {
+	msg := rawQuery(1, "example.com", typeA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery: %v", err)
+	}
+
+	ips := []net.IP{net.ParseIP("198.18.0.1"), net.ParseIP("198.18.0.2")}
+	resp := buildResponse(msg, q, ips, false, time.Minute)
+
+	ancount := binary.BigEndian.Uint16(resp[6:8])
+	if ancount != 2 {
+		t.Fatalf("ANCOUNT = %d, want 2", ancount)
+	}
+}
+
+// TestBuildResponseANCOUNTExcludesSkippedFamily covers a DirectDomain A
+// query whose upstream (net.DefaultResolver.LookupIPAddr) returned both
+// families: the AAAA record must be skipped, not counted, so ANCOUNT
+// matches the records actually written.
+func TestBuildResponseANCOUNTExcludesSkippedFamily(t *testing.T) {
+	msg := rawQuery(1, "example.com", typeA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery: %v", err)
+	}
+
+	ips := []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("2606:2800:220:1:248:1893:25c8:1946")}
+	resp := buildResponse(msg, q, ips, false, time.Minute)
+
+	ancount := binary.BigEndian.Uint16(resp[6:8])
+	if ancount != 1 {
+		t.Fatalf("ANCOUNT = %d, want 1 (the AAAA address must be skipped for an A query)", ancount)
+	}
+}
+
+func TestBuildResponseAAAADoesNotAnswerIPv4Addresses(t *testing.T) {
+	msg := rawQuery(1, "example.com", typeAAAA)
+	q, err := parseQuery(msg)
+	if err != nil {
+		t.Fatalf("parseQuery: %v", err)
+	}
+
+	ips := []net.IP{net.ParseIP("93.184.216.34")}
+	resp := buildResponse(msg, q, ips, false, time.Minute)
+
+	ancount := binary.BigEndian.Uint16(resp[6:8])
+	if ancount != 0 {
+		t.Fatalf("ANCOUNT = %d, want 0 (an IPv4 address must not answer an AAAA query)", ancount)
+	}
+}
@@ -0,0 +1,147 @@
+package fakedns
+
+import (
+	"container/list"
+	"fmt"
+	"net"
+	"sync"
+)
+
+// maxPoolEntries bounds how many live domain->IP mappings a Pool keeps
+// regardless of how large its CIDR is, since the fake-IP space only
+// needs to be as big as the number of distinct domains seen recently,
+// not the whole address range.
+const maxPoolEntries = 65536
+
+// entry is one live domain<->fake-IP mapping, held in Pool.order so the
+// least-recently-used one can be evicted and its index reused.
+type entry struct {
+	domain string
+	ip     net.IP
+	index  uint32
+}
+
+// Pool allocates stable fake IPv4/IPv6 addresses from a CIDR range for
+// domain names, an LRU eviction policy reclaiming addresses once the
+// range (or maxPoolEntries, whichever is smaller) fills up. It also
+// keeps the reverse mapping so a fake-IP destination can be resolved
+// back to the domain that earned it.
+type Pool struct {
+	base net.IP
+	size uint32 // usable addresses, excluding the network address at index 0
+
+	mu       sync.Mutex
+	byDomain map[string]*list.Element
+	byIP     map[string]*list.Element
+	order    *list.List // front = most recently used
+}
+
+// NewPool builds a Pool over cidr, e.g. "198.18.0.0/15" or "fc00::/18".
+func NewPool(cidr string) (*Pool, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("fakedns: parse cidr %q: %w", cidr, err)
+	}
+	ones, bits := ipnet.Mask.Size()
+	if bits-ones >= 32 {
+		// Cap the usable range at maxPoolEntries rather than overflowing
+		// the uint32 index arithmetic below.
+		ones = bits - 32
+	}
+	total := uint64(1) << uint(bits-ones)
+	size := total - 1 // reserve the network address
+	if size > maxPoolEntries {
+		size = maxPoolEntries
+	}
+	if size < 2 {
+		return nil, fmt.Errorf("fakedns: cidr %q too small", cidr)
+	}
+
+	return &Pool{
+		base:     ipnet.IP,
+		size:     uint32(size),
+		byDomain: make(map[string]*list.Element),
+		byIP:     make(map[string]*list.Element),
+		order:    list.New(),
+	}, nil
+}
+
+// Allocate returns the fake IP for domain, reusing its existing mapping
+// if one is live. Once the pool is full, it evicts the least-recently-
+// used domain to free an index for the new one.
+func (p *Pool) Allocate(domain string) net.IP {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if el, ok := p.byDomain[domain]; ok {
+		p.order.MoveToFront(el)
+		return el.Value.(*entry).ip
+	}
+
+	var idx uint32
+	if uint32(p.order.Len()) < p.size {
+		idx = uint32(p.order.Len()) + 1
+	} else {
+		back := p.order.Back()
+		old := back.Value.(*entry)
+		delete(p.byDomain, old.domain)
+		delete(p.byIP, old.ip.String())
+		p.order.Remove(back)
+		idx = old.index
+	}
+
+	ip := indexToIP(p.base, idx)
+	e := &entry{domain: domain, ip: ip, index: idx}
+	el := p.order.PushFront(e)
+	p.byDomain[domain] = el
+	p.byIP[ip.String()] = el
+	return ip
+}
+
+// Lookup reverses a previously allocated fake IP back to its domain. ok
+// is false if ip was never allocated or has since been evicted.
+func (p *Pool) Lookup(ip net.IP) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	el, ok := p.byIP[ip.String()]
+	if !ok {
+		return "", false
+	}
+	p.order.MoveToFront(el)
+	return el.Value.(*entry).domain, true
+}
+
+// Allocation is a snapshot of one live domain<->fake-IP mapping, for
+// display (e.g. the TUI's fake-DNS allocations page).
+type Allocation struct {
+	Domain string
+	IP     net.IP
+}
+
+// Allocations returns every live mapping, most-recently-used first.
+func (p *Pool) Allocations() []Allocation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Allocation, 0, p.order.Len())
+	for el := p.order.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*entry)
+		out = append(out, Allocation{Domain: e.domain, IP: e.ip})
+	}
+	return out
+}
+
+// indexToIP returns a copy of base with idx added to its low 32 bits
+// (with carry), treating base as a big-endian number. This keeps IPv4
+// and IPv6 pools on the same code path since Pool never allocates more
+// than maxPoolEntries addresses, which always fits in 32 bits.
+func indexToIP(base net.IP, idx uint32) net.IP {
+	ip := make(net.IP, len(base))
+	copy(ip, base)
+	for i := 0; i < 4 && idx > 0; i++ {
+		pos := len(ip) - 1 - i
+		sum := uint32(ip[pos]) + idx
+		ip[pos] = byte(sum)
+		idx = sum >> 8
+	}
+	return ip
+}
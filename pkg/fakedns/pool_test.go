@@ -0,0 +1,97 @@
+package fakedns
+
+import (
+	"net"
+	"testing"
+)
+
+func TestAllocateIsStablePerDomain(t *testing.T) {
+	p, err := NewPool("198.18.0.0/15")
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	ip1 := p.Allocate("example.com")
+	ip2 := p.Allocate("example.com")
+	if !ip1.Equal(ip2) {
+		t.Fatalf("Allocate(example.com) = %v then %v, want the same IP", ip1, ip2)
+	}
+
+	other := p.Allocate("other.com")
+	if ip1.Equal(other) {
+		t.Fatalf("different domains got the same fake IP %v", ip1)
+	}
+}
+
+func TestLookupReversesAllocation(t *testing.T) {
+	p, err := NewPool("198.18.0.0/15")
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	ip := p.Allocate("example.com")
+	domain, ok := p.Lookup(ip)
+	if !ok || domain != "example.com" {
+		t.Fatalf("Lookup(%v) = (%q, %v), want (example.com, true)", ip, domain, ok)
+	}
+
+	if _, ok := p.Lookup(net.ParseIP("203.0.113.1")); ok {
+		t.Fatal("Lookup: want ok=false for an address never allocated")
+	}
+}
+
+func TestAllocateEvictsLeastRecentlyUsedWhenFull(t *testing.T) {
+	// A /30 reserves only the network address, leaving 3 usable addresses.
+	p, err := NewPool("10.0.0.0/30")
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+
+	_ = p.Allocate("a.com")
+	ipB := p.Allocate("b.com")
+	_ = p.Allocate("c.com")
+	// Pool is now full (3 entries). Touch a.com so b.com becomes the LRU
+	// entry: order front-to-back is a, c, b.
+	p.Allocate("a.com")
+
+	ipD := p.Allocate("d.com")
+	if !ipD.Equal(ipB) {
+		t.Fatalf("d.com got %v, want it to reuse b.com's freed IP %v as the evicted LRU entry", ipD, ipB)
+	}
+	if domain, ok := p.Lookup(ipD); !ok || domain != "d.com" {
+		t.Fatalf("Lookup(%v) = (%q, %v), want (d.com, true) after eviction", ipD, domain, ok)
+	}
+	if _, ok := p.byDomain["b.com"]; ok {
+		t.Fatal("b.com: want evicted as the least-recently-used entry")
+	}
+}
+
+func TestNewPoolRejectsTooSmallRange(t *testing.T) {
+	if _, err := NewPool("10.0.0.0/31"); err == nil {
+		t.Fatal("NewPool(/31): want error, too small to allocate from")
+	}
+}
+
+func TestIndexToIPCarries(t *testing.T) {
+	base := net.ParseIP("10.0.0.255").To4()
+	got := indexToIP(base, 1)
+	want := net.ParseIP("10.0.1.0").To4()
+	if !got.Equal(want) {
+		t.Fatalf("indexToIP(%v, 1) = %v, want %v", base, got, want)
+	}
+}
+
+func TestAllocationsMostRecentlyUsedFirst(t *testing.T) {
+	p, err := NewPool("198.18.0.0/15")
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	p.Allocate("a.com")
+	p.Allocate("b.com")
+	p.Allocate("a.com") // touch a.com again
+
+	allocs := p.Allocations()
+	if len(allocs) != 2 || allocs[0].Domain != "a.com" {
+		t.Fatalf("Allocations() = %v, want a.com first", allocs)
+	}
+}
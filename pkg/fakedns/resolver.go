@@ -0,0 +1,165 @@
+// Package fakedns implements a Clash-style fake-IP DNS resolver: most
+// domains get a stable synthetic address out of a small LRU-backed CIDR
+// pool instead of their real A/AAAA record, so TUN-mode traffic that
+// applications address by (system-resolved) IP still carries enough
+// domain context for ruleset's domain-based routing rules. A handful of
+// configured domains (typically the ones already routed direct, e.g.
+// LAN/private-resolving hostnames) bypass the fake pool and get a real
+// answer instead.
+package fakedns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Config controls which domains get a fake IP versus a real DNS answer,
+// the address pools fake answers are allocated from, and the TTL
+// returned to clients.
+type Config struct {
+	Listen    string        // e.g. "127.0.0.1:10853"
+	IPv4Range string        // CIDR, e.g. "198.18.0.0/15"
+	IPv6Range string        // CIDR, e.g. "fc00::/18"
+	TTL       time.Duration
+
+	// DirectDomains are suffix-matched against the query name; a match
+	// resolves via Upstream instead of the fake pool.
+	DirectDomains []string
+
+	// Upstream resolves domain to its real address(es), for
+	// DirectDomains. Defaults to net.DefaultResolver.LookupIPAddr.
+	Upstream func(ctx context.Context, domain string) ([]net.IP, error)
+}
+
+// Resolver serves DNS queries on Config.Listen.
+type Resolver struct {
+	cfg Config
+	v4  *Pool
+	v6  *Pool
+}
+
+// NewResolver builds a Resolver from cfg. It does not start listening;
+// call Run.
+func NewResolver(cfg Config) (*Resolver, error) {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 60 * time.Second
+	}
+	if cfg.Upstream == nil {
+		cfg.Upstream = defaultUpstream
+	}
+	v4, err := NewPool(cfg.IPv4Range)
+	if err != nil {
+		return nil, err
+	}
+	var v6 *Pool
+	if cfg.IPv6Range != "" {
+		v6, err = NewPool(cfg.IPv6Range)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Resolver{cfg: cfg, v4: v4, v6: v6}, nil
+}
+
+func defaultUpstream(ctx context.Context, domain string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, len(addrs))
+	for i, a := range addrs {
+		ips[i] = a.IP
+	}
+	return ips, nil
+}
+
+// V4 returns the IPv4 fake-IP pool, for a TUI allocations page.
+func (r *Resolver) V4() *Pool { return r.v4 }
+
+// V6 returns the IPv6 fake-IP pool, or nil if Config.IPv6Range was
+// empty.
+func (r *Resolver) V6() *Pool { return r.v6 }
+
+// Reverse resolves a previously-handed-out fake IP back to the domain
+// that earned it, checking both pools. ok is false for a real (non-fake)
+// address or one that's been evicted.
+func (r *Resolver) Reverse(ip net.IP) (string, bool) {
+	if domain, ok := r.v4.Lookup(ip); ok {
+		return domain, true
+	}
+	if r.v6 != nil {
+		if domain, ok := r.v6.Lookup(ip); ok {
+			return domain, true
+		}
+	}
+	return "", false
+}
+
+func (r *Resolver) isDirect(domain string) bool {
+	domain = strings.TrimSuffix(domain, ".")
+	for _, suffix := range r.cfg.DirectDomains {
+		if domain == suffix || strings.HasSuffix(domain, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run listens on Config.Listen and answers queries until ctx is
+// cancelled.
+func (r *Resolver) Run(ctx context.Context) error {
+	addr, err := net.ResolveUDPAddr("udp", r.cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("fakedns: resolve listen addr: %w", err)
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return fmt.Errorf("fakedns: listen: %w", err)
+	}
+	defer conn.Close()
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 512)
+	for {
+		n, client, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+		msg := append([]byte(nil), buf[:n]...)
+		go r.handle(ctx, conn, client, msg)
+	}
+}
+
+func (r *Resolver) handle(ctx context.Context, conn *net.UDPConn, client *net.UDPAddr, rawQuery []byte) {
+	q, err := parseQuery(rawQuery)
+	if err != nil {
+		return
+	}
+
+	var ips []net.IP
+	var nxdomain bool
+	switch {
+	case r.isDirect(q.name):
+		uctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		var err error
+		ips, err = r.cfg.Upstream(uctx, q.name)
+		cancel()
+		nxdomain = err != nil
+	case q.qtype == typeA:
+		ips = []net.IP{r.v4.Allocate(q.name)}
+	case q.qtype == typeAAAA && r.v6 != nil:
+		ips = []net.IP{r.v6.Allocate(q.name)}
+	}
+
+	conn.WriteToUDP(buildResponse(rawQuery, q, ips, nxdomain, r.cfg.TTL), client)
+}
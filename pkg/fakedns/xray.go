@@ -0,0 +1,37 @@
+package fakedns
+
+// XrayDNS returns the Xray "dns" and "fakedns" config blocks matching
+// cfg: one fakedns pool per range, each with poolSize maxPoolEntries
+// whatever the range's own size, and a dns.servers list that
+// resolves DirectDomains through a real server while everything else
+// falls through to the fakedns object. This lets Xray's own DNS client
+// (used by inbounds sniffing for fakeDNS destOverride) allocate from the
+// same ranges our Resolver does, independent of whether Run's UDP
+// listener is also reachable from the client.
+func (cfg Config) XrayDNS() (dns map[string]interface{}, fakedns []map[string]interface{}) {
+	fakedns = []map[string]interface{}{
+		{
+			"ipPool":   cfg.IPv4Range,
+			"poolSize": maxPoolEntries,
+		},
+	}
+	if cfg.IPv6Range != "" {
+		fakedns = append(fakedns, map[string]interface{}{
+			"ipPool":   cfg.IPv6Range,
+			"poolSize": maxPoolEntries,
+		})
+	}
+
+	servers := []interface{}{"fakedns"}
+	for _, domain := range cfg.DirectDomains {
+		servers = append(servers, map[string]interface{}{
+			"address": "1.1.1.1",
+			"domains": []string{"domain:" + domain},
+		})
+	}
+
+	dns = map[string]interface{}{
+		"servers": servers,
+	}
+	return dns, fakedns
+}
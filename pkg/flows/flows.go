@@ -0,0 +1,235 @@
+// Package flows tracks individual proxy connections (5-tuple, owning
+// process, matched routing rule, sniffed SNI, and live throughput) instead
+// of only the aggregated per-tag counters Xray's stats service exposes.
+package flows
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// Flow is one tracked connection, enriched with the owning process name
+// and a speed EMA on top of the raw byte counters Xray reports.
+type Flow struct {
+	ID          string
+	Network     string
+	Process     string
+	Host        string // sniffed SNI/hostname, falls back to dest IP
+	DestAddr    string
+	OutboundTag string
+	RuleTag     string
+	Start       time.Time
+	Upload      int64
+	Download    int64
+	UpSpeed     float64 // EMA, bytes/sec
+	DownSpeed   float64
+}
+
+const emaAlpha = 0.3
+
+// Tracker polls GetActiveConnections for one Xray instance on an interval
+// and maintains an enriched, speed-smoothed view of each flow.
+type Tracker struct {
+	instanceID string
+	interval   time.Duration
+
+	mu      sync.RWMutex
+	flows   map[string]*Flow
+	lastAt  time.Time
+
+	procLookup func(network, destAddr string) string
+}
+
+// NewTracker builds a Tracker for instanceID. procLookup resolves the
+// owning process name for a connection (via netstat/gopsutil on the
+// caller's platform); pass nil to leave Process empty.
+func NewTracker(instanceID string, interval time.Duration, procLookup func(network, destAddr string) string) *Tracker {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Tracker{
+		instanceID: instanceID,
+		interval:   interval,
+		flows:      make(map[string]*Flow),
+		procLookup: procLookup,
+	}
+}
+
+// Run polls until ctx is cancelled.
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.poll()
+		}
+	}
+}
+
+func (t *Tracker) poll() {
+	conns, err := superray.GetActiveConnections(t.instanceID)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	elapsed := now.Sub(t.lastAt).Seconds()
+	if elapsed <= 0 {
+		elapsed = t.interval.Seconds()
+	}
+	t.lastAt = now
+
+	seen := make(map[string]bool, len(conns))
+	for _, c := range conns {
+		seen[c.ID] = true
+		f, ok := t.flows[c.ID]
+		if !ok {
+			f = &Flow{
+				ID:       c.ID,
+				Network:  c.Network,
+				DestAddr: c.DestAddr,
+				Start:    time.Unix(c.StartUnix, 0),
+			}
+			if t.procLookup != nil {
+				f.Process = t.procLookup(c.Network, c.DestAddr)
+			}
+			t.flows[c.ID] = f
+		}
+
+		f.Host = c.SNI
+		if f.Host == "" {
+			f.Host = c.DestAddr
+		}
+		f.OutboundTag = c.OutboundTag
+		f.RuleTag = c.RuleTag
+
+		upDelta := float64(c.Uplink - f.Upload)
+		downDelta := float64(c.Downlink - f.Downlink)
+		f.UpSpeed = ema(f.UpSpeed, upDelta/elapsed)
+		f.DownSpeed = ema(f.DownSpeed, downDelta/elapsed)
+		f.Upload = c.Uplink
+		f.Download = c.Downlink
+	}
+
+	// Drop flows Xray no longer reports (connection closed).
+	for id := range t.flows {
+		if !seen[id] {
+			delete(t.flows, id)
+		}
+	}
+	t.mu.Unlock()
+}
+
+func ema(prev, sample float64) float64 {
+	if prev == 0 {
+		return sample
+	}
+	return emaAlpha*sample + (1-emaAlpha)*prev
+}
+
+// Kill closes a tracked flow through Xray's HandlerService and removes it
+// from the tracker immediately rather than waiting for the next poll.
+func (t *Tracker) Kill(id string) error {
+	if err := superray.CloseConnection(t.instanceID, id); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	delete(t.flows, id)
+	t.mu.Unlock()
+	return nil
+}
+
+// SortMode selects the ordering returned by Snapshot.
+type SortMode int
+
+const (
+	SortByStart SortMode = iota
+	SortByUpSpeed
+	SortByDownSpeed
+	SortByTotal
+)
+
+// Filter narrows a Snapshot by process name or host/SNI substring
+// (case-insensitive): a flow is kept if it matches either non-empty
+// field. With both empty, every flow is kept.
+type Filter struct {
+	Process string
+	Host    string
+}
+
+// Snapshot returns a copy of the currently tracked flows, optionally
+// filtered and sorted. Flows that tie on the sort key come back in no
+// particular order.
+func (t *Tracker) Snapshot(f Filter, sortBy SortMode) []*Flow {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make([]*Flow, 0, len(t.flows))
+	for _, fl := range t.flows {
+		if f.Process != "" || f.Host != "" {
+			procMatch := f.Process != "" && strings.Contains(strings.ToLower(fl.Process), strings.ToLower(f.Process))
+			hostMatch := f.Host != "" && strings.Contains(strings.ToLower(fl.Host), strings.ToLower(f.Host))
+			if !procMatch && !hostMatch {
+				continue
+			}
+		}
+		cp := *fl
+		out = append(out, &cp)
+	}
+
+	switch sortBy {
+	case SortByUpSpeed:
+		sort.Slice(out, func(i, j int) bool { return out[i].UpSpeed > out[j].UpSpeed })
+	case SortByDownSpeed:
+		sort.Slice(out, func(i, j int) bool { return out[i].DownSpeed > out[j].DownSpeed })
+	case SortByTotal:
+		sort.Slice(out, func(i, j int) bool {
+			return out[i].Upload+out[i].Download > out[j].Upload+out[j].Download
+		})
+	default:
+		sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	}
+	return out
+}
+
+// TopTalkers aggregates flows by remote host, returning the top N by total
+// bytes transferred.
+func (t *Tracker) TopTalkers(n int) []*Flow {
+	t.mu.RLock()
+	byHost := make(map[string]*Flow, len(t.flows))
+	for _, fl := range t.flows {
+		agg, ok := byHost[fl.Host]
+		if !ok {
+			cp := *fl
+			byHost[fl.Host] = &cp
+			continue
+		}
+		agg.Upload += fl.Upload
+		agg.Download += fl.Download
+		agg.UpSpeed += fl.UpSpeed
+		agg.DownSpeed += fl.DownSpeed
+	}
+	t.mu.RUnlock()
+
+	out := make([]*Flow, 0, len(byHost))
+	for _, f := range byHost {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Upload+out[i].Download > out[j].Upload+out[j].Download
+	})
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package flows
+
+import (
+	"strings"
+
+	gopsnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// LookupProcess resolves the local process owning a connection to
+// destAddr by matching against the OS connection table (via gopsutil,
+// which covers Linux/macOS/Windows). Returns "" if no match is found.
+func LookupProcess(network, destAddr string) string {
+	kind := "tcp"
+	if strings.HasPrefix(strings.ToLower(network), "udp") {
+		kind = "udp"
+	}
+
+	conns, err := gopsnet.Connections(kind)
+	if err != nil {
+		return ""
+	}
+
+	for _, c := range conns {
+		remote := c.Raddr.IP + ":" + itoa(c.Raddr.Port)
+		if remote != destAddr {
+			continue
+		}
+		if c.Pid == 0 {
+			return ""
+		}
+		p, err := process.NewProcess(c.Pid)
+		if err != nil {
+			return ""
+		}
+		name, err := p.Name()
+		if err != nil {
+			return ""
+		}
+		return name
+	}
+	return ""
+}
+
+func itoa(port uint32) string {
+	if port == 0 {
+		return "0"
+	}
+	digits := [10]byte{}
+	i := len(digits)
+	for port > 0 {
+		i--
+		digits[i] = byte('0' + port%10)
+		port /= 10
+	}
+	return string(digits[i:])
+}
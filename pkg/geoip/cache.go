@@ -0,0 +1,98 @@
+package geoip
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// entry is what gets persisted to disk for each cached IP.
+type entry struct {
+	Info     *Info     `json:"info"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Cache is a unified GeoIP result cache that lives in memory and is
+// flushed to a JSON file so lookups survive restarts.
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]entry
+	dirty   bool
+}
+
+// DefaultCachePath returns ~/.superray/geoip.cache.json.
+func DefaultCachePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".superray", "geoip.cache.json")
+}
+
+// LoadCache reads the cache file at path if it exists, returning an empty
+// cache otherwise.
+func LoadCache(path string) *Cache {
+	c := &Cache{path: path, entries: make(map[string]entry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	var onDisk map[string]entry
+	if err := json.Unmarshal(data, &onDisk); err == nil {
+		c.entries = onDisk
+	}
+	return c
+}
+
+// Get returns a cached Info for ip if present and fresher than ttl.
+func (c *Cache) Get(ip string, ttl time.Duration) (*Info, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[ip]
+	if !ok || time.Since(e.FetchedAt) > ttl {
+		return nil, false
+	}
+	return e.Info, true
+}
+
+// Put stores info for ip and marks the cache dirty for the next Save.
+func (c *Cache) Put(ip string, info *Info) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[ip] = entry{Info: info, FetchedAt: time.Now()}
+	c.dirty = true
+}
+
+// Len returns the number of entries currently held in the cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Save writes the cache to disk if it has changed since the last Save.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
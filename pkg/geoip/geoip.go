@@ -0,0 +1,136 @@
+// Package geoip provides a pluggable multi-provider IP geolocation lookup
+// chain with an on-disk cache, so the TUI is not hard-wired to a single
+// online service and can still resolve GeoIP info offline via a local
+// MaxMind MMDB database.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Info is the normalized result of a GeoIP lookup, shared by all providers.
+type Info struct {
+	IP          string `json:"ip"`
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+	Region      string `json:"region"`
+	RegionName  string `json:"regionName"`
+	City        string `json:"city"`
+	ISP         string `json:"isp"`
+	Org         string `json:"org"`
+	AS          string `json:"as"`
+	ASName      string `json:"asname"`
+	Query       string `json:"query"`
+	Status      string `json:"status"`
+	Provider    string `json:"provider"`
+}
+
+// Provider is implemented by each GeoIP backend (online API or offline
+// MMDB reader).
+type Provider interface {
+	// Name identifies the provider, used in config (e.g. "ipapi", "mmdb").
+	Name() string
+	// Lookup resolves ip to geo/ASN info.
+	Lookup(ctx context.Context, ip string) (*Info, error)
+}
+
+// providerState tracks per-provider health for circuit-breaking.
+type providerState struct {
+	mu             sync.Mutex
+	lastFailure    time.Time
+	consecutiveErr int
+	rateLimiter    *time.Ticker
+	nextAllowed    time.Time
+}
+
+// breakDuration is how long a provider is skipped after tripping the
+// circuit breaker.
+const breakDuration = 2 * time.Minute
+
+// maxConsecutiveErr trips the breaker after this many failures in a row.
+const maxConsecutiveErr = 3
+
+// Chain tries a list of providers in order, skipping ones that are
+// circuit-broken (maxConsecutiveErr failures in a row, for
+// breakDuration), and caches successful results. A provider's
+// rate-limit reply counts as an ordinary failure; the Chain puts no
+// request-rate cap of its own on any provider.
+type Chain struct {
+	providers []Provider
+	states    map[string]*providerState
+	cache     *Cache
+	ttl       time.Duration
+}
+
+// NewChain builds a Chain that tries providers in the given order. ttl
+// controls how long a cached lookup is considered fresh; cache may be nil
+// to disable persistence (in-memory only via the Chain's own TTL checks
+// is then the caller's responsibility).
+func NewChain(providers []Provider, cache *Cache, ttl time.Duration) *Chain {
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	states := make(map[string]*providerState, len(providers))
+	for _, p := range providers {
+		states[p.Name()] = &providerState{}
+	}
+	return &Chain{providers: providers, states: states, cache: cache, ttl: ttl}
+}
+
+// Names returns the configured provider order, for display in the TUI.
+func (c *Chain) Names() []string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return names
+}
+
+// Lookup resolves ip through the provider chain, returning the first
+// successful result. Failed providers are retried on the next healthy
+// window rather than permanently disabled.
+func (c *Chain) Lookup(ctx context.Context, ip string) (*Info, error) {
+	if c.cache != nil {
+		if info, ok := c.cache.Get(ip, c.ttl); ok {
+			return info, nil
+		}
+	}
+
+	var lastErr error
+	for _, p := range c.providers {
+		state := c.states[p.Name()]
+		state.mu.Lock()
+		skip := state.consecutiveErr >= maxConsecutiveErr && time.Since(state.lastFailure) < breakDuration
+		rateLimited := time.Now().Before(state.nextAllowed)
+		state.mu.Unlock()
+		if skip || rateLimited {
+			continue
+		}
+
+		info, err := p.Lookup(ctx, ip)
+		state.mu.Lock()
+		if err != nil {
+			state.consecutiveErr++
+			state.lastFailure = time.Now()
+			state.mu.Unlock()
+			lastErr = err
+			continue
+		}
+		state.consecutiveErr = 0
+		state.mu.Unlock()
+
+		info.Provider = p.Name()
+		if c.cache != nil {
+			c.cache.Put(ip, info)
+		}
+		return info, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("geoip: no provider available for %s", ip)
+	}
+	return nil, lastErr
+}
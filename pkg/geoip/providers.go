@@ -0,0 +1,257 @@
+package geoip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	maxminddb "github.com/oschwald/maxminddb-golang"
+)
+
+func parseIP(s string) net.IP {
+	return net.ParseIP(s)
+}
+
+// IPAPIProvider queries the free ip-api.com JSON API (no key required,
+// ~45 requests/minute).
+type IPAPIProvider struct {
+	Client *http.Client
+}
+
+func NewIPAPIProvider() *IPAPIProvider {
+	return &IPAPIProvider{Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (p *IPAPIProvider) Name() string { return "ipapi" }
+
+func (p *IPAPIProvider) Lookup(ctx context.Context, ip string) (*Info, error) {
+	url := fmt.Sprintf("http://ip-api.com/json/%s?fields=status,country,countryCode,region,regionName,city,isp,org,as,asname,query", ip)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ip-api.com: status %d", resp.StatusCode)
+	}
+	var info Info
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	if info.Status != "success" {
+		return nil, fmt.Errorf("ip-api.com: lookup failed for %s", ip)
+	}
+	return &info, nil
+}
+
+// IPInfoProvider queries ipinfo.io, optionally with a bearer token from
+// GEOIP_TOKEN_IPINFO for higher rate limits.
+type IPInfoProvider struct {
+	Token  string
+	Client *http.Client
+}
+
+func NewIPInfoProvider(token string) *IPInfoProvider {
+	return &IPInfoProvider{Token: token, Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (p *IPInfoProvider) Name() string { return "ipinfo" }
+
+func (p *IPInfoProvider) Lookup(ctx context.Context, ip string) (*Info, error) {
+	url := fmt.Sprintf("https://ipinfo.io/%s/json", ip)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ipinfo.io: status %d", resp.StatusCode)
+	}
+
+	var raw struct {
+		IP      string `json:"ip"`
+		City    string `json:"city"`
+		Region  string `json:"region"`
+		Country string `json:"country"`
+		Org     string `json:"org"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return &Info{
+		IP:          raw.IP,
+		Query:       raw.IP,
+		City:        raw.City,
+		Region:      raw.Region,
+		RegionName:  raw.Region,
+		CountryCode: raw.Country,
+		Country:     raw.Country,
+		Org:         raw.Org,
+		Status:      "success",
+	}, nil
+}
+
+// IPSBProvider queries the ip.sb GeoIP API.
+type IPSBProvider struct {
+	Client *http.Client
+}
+
+func NewIPSBProvider() *IPSBProvider {
+	return &IPSBProvider{Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (p *IPSBProvider) Name() string { return "ip.sb" }
+
+func (p *IPSBProvider) Lookup(ctx context.Context, ip string) (*Info, error) {
+	url := fmt.Sprintf("https://api.ip.sb/geoip/%s", ip)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ip.sb: status %d", resp.StatusCode)
+	}
+
+	var raw struct {
+		IP             string `json:"ip"`
+		Country        string `json:"country"`
+		CountryCode    string `json:"country_code"`
+		City           string `json:"city"`
+		Region         string `json:"region"`
+		ASN            int    `json:"asn"`
+		ASNOrganization string `json:"asn_organization"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return &Info{
+		IP:          raw.IP,
+		Query:       raw.IP,
+		Country:     raw.Country,
+		CountryCode: raw.CountryCode,
+		City:        raw.City,
+		Region:      raw.Region,
+		RegionName:  raw.Region,
+		AS:          fmt.Sprintf("AS%d", raw.ASN),
+		ASName:      raw.ASNOrganization,
+		Org:         raw.ASNOrganization,
+		Status:      "success",
+	}, nil
+}
+
+// MMDBProvider resolves IPs entirely offline against local MaxMind
+// GeoLite2-City.mmdb and GeoLite2-ASN.mmdb databases. It is the only
+// provider that works without network access, so it should generally be
+// placed first when the client may run with the proxy down.
+type MMDBProvider struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+// OpenMMDBProvider opens GeoLite2-City.mmdb and GeoLite2-ASN.mmdb under
+// dir. Either file may be absent; the provider degrades to whichever
+// database is available, and returns an error only if neither opens.
+func OpenMMDBProvider(dir string) (*MMDBProvider, error) {
+	p := &MMDBProvider{}
+	cityPath := dir + "/GeoLite2-City.mmdb"
+	asnPath := dir + "/GeoLite2-ASN.mmdb"
+
+	if _, err := os.Stat(cityPath); err == nil {
+		if r, err := maxminddb.Open(cityPath); err == nil {
+			p.city = r
+		}
+	}
+	if _, err := os.Stat(asnPath); err == nil {
+		if r, err := maxminddb.Open(asnPath); err == nil {
+			p.asn = r
+		}
+	}
+	if p.city == nil && p.asn == nil {
+		return nil, fmt.Errorf("geoip: no MMDB databases found under %s", dir)
+	}
+	return p, nil
+}
+
+func (p *MMDBProvider) Name() string { return "mmdb" }
+
+func (p *MMDBProvider) Close() error {
+	var firstErr error
+	if p.city != nil {
+		firstErr = p.city.Close()
+	}
+	if p.asn != nil {
+		if err := p.asn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (p *MMDBProvider) Lookup(ctx context.Context, ipStr string) (*Info, error) {
+	ip := parseIP(ipStr)
+	if ip == nil {
+		return nil, fmt.Errorf("mmdb: invalid IP %q", ipStr)
+	}
+
+	info := &Info{IP: ipStr, Query: ipStr, Status: "success"}
+
+	if p.city != nil {
+		var rec struct {
+			Country struct {
+				Names map[string]string `maxminddb:"names"`
+				ISOCode string         `maxminddb:"iso_code"`
+			} `maxminddb:"country"`
+			City struct {
+				Names map[string]string `maxminddb:"names"`
+			} `maxminddb:"city"`
+			Subdivisions []struct {
+				Names map[string]string `maxminddb:"names"`
+			} `maxminddb:"subdivisions"`
+		}
+		if err := p.city.Lookup(ip, &rec); err == nil {
+			info.Country = rec.Country.Names["en"]
+			info.CountryCode = rec.Country.ISOCode
+			info.City = rec.City.Names["en"]
+			if len(rec.Subdivisions) > 0 {
+				info.RegionName = rec.Subdivisions[0].Names["en"]
+			}
+		}
+	}
+
+	if p.asn != nil {
+		var rec struct {
+			AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+			AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+		}
+		if err := p.asn.Lookup(ip, &rec); err == nil && rec.AutonomousSystemNumber != 0 {
+			info.AS = fmt.Sprintf("AS%d %s", rec.AutonomousSystemNumber, rec.AutonomousSystemOrganization)
+			info.ASName = rec.AutonomousSystemOrganization
+			info.Org = rec.AutonomousSystemOrganization
+		}
+	}
+
+	if info.Country == "" && info.AS == "" {
+		return nil, fmt.Errorf("mmdb: no record for %s", ipStr)
+	}
+	return info, nil
+}
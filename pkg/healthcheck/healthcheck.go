@@ -0,0 +1,301 @@
+// Package healthcheck continuously probes a set of servers through their
+// actual outbound (not a direct connection) and turns the results into a
+// quality score, so the TUI can show at-a-glance server health and
+// optionally auto-failover away from a degraded connection.
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// windowSize is the number of recent samples kept per server.
+const windowSize = 20
+
+// ProbeURL is fetched through each candidate outbound as an HTTP HEAD to
+// measure end-to-end reachability, independent of raw TCP connect time.
+const ProbeURL = "http://cp.cloudflare.com/generate_204"
+
+// Sample is one probe result for a server.
+type Sample struct {
+	Time      time.Time
+	TCPRTTMs  int
+	HTTPRTTMs int
+	Success   bool
+}
+
+// Score summarizes a server's rolling window.
+type Score struct {
+	MedianRTTMs float64
+	JitterMs    float64 // stdev of RTT samples
+	SuccessRate float64 // 0..1
+	Value       float64 // higher is better
+	Samples     int
+}
+
+// key identifies a server independent of display name changes.
+type key struct {
+	Address string
+	Port    int
+}
+
+func keyOf(s *superray.Server) key { return key{Address: s.Address, Port: s.Port} }
+
+// Scheduler probes a fixed server list on a cadence, maintains a rolling
+// window of samples per server, and can automatically fail over the
+// active connection when its score degrades.
+type Scheduler struct {
+	mu      sync.Mutex
+	windows map[key][]Sample
+	servers []*superray.Server
+
+	Interval      time.Duration
+	Concurrency   int
+	FailThreshold int     // consecutive failed probes before considering failover
+	ScoreFloor    float64 // Auto mode switches away once active score drops below this
+
+	// ProbeThroughOutbound performs one probe of server through its own
+	// outbound (not a direct connection) and returns the sample. Exposed
+	// as a field so it can be swapped in tests; production code should
+	// use DefaultProbe, which spins up a throwaway single-outbound Xray
+	// instance per probe.
+	ProbeThroughOutbound func(ctx context.Context, server *superray.Server) Sample
+
+	auto        bool
+	active      key
+	consecFails int
+	onFailover  func(next *superray.Server, reason string)
+}
+
+// NewScheduler builds a Scheduler for servers. interval and concurrency
+// fall back to sane defaults when non-positive.
+func NewScheduler(servers []*superray.Server, interval time.Duration, concurrency int) *Scheduler {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	return &Scheduler{
+		windows:              make(map[key][]Sample),
+		servers:              servers,
+		Interval:             interval,
+		Concurrency:          concurrency,
+		FailThreshold:        3,
+		ScoreFloor:           20,
+		ProbeThroughOutbound: DefaultProbe,
+	}
+}
+
+// SetActive marks which server is currently connected, for auto-failover
+// tracking.
+func (s *Scheduler) SetActive(server *superray.Server) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if server == nil {
+		s.active = key{}
+		return
+	}
+	s.active = keyOf(server)
+	s.consecFails = 0
+}
+
+// SetAuto enables or disables automatic failover.
+func (s *Scheduler) SetAuto(enabled bool, onFailover func(next *superray.Server, reason string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auto = enabled
+	s.onFailover = onFailover
+}
+
+// Run probes every server once per Interval until ctx is cancelled,
+// bounded to Concurrency probes in flight at a time.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.probeAll(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) probeAll(ctx context.Context) {
+	s.mu.Lock()
+	servers := make([]*superray.Server, len(s.servers))
+	copy(servers, s.servers)
+	s.mu.Unlock()
+
+	sem := make(chan struct{}, s.Concurrency)
+	var wg sync.WaitGroup
+
+	for _, server := range servers {
+		server := server
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			sample := s.ProbeThroughOutbound(ctx, server)
+			s.record(server, sample)
+		}()
+	}
+	wg.Wait()
+
+	s.checkFailover()
+}
+
+func (s *Scheduler) record(server *superray.Server, sample Sample) {
+	k := keyOf(server)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	win := append(s.windows[k], sample)
+	if len(win) > windowSize {
+		win = win[len(win)-windowSize:]
+	}
+	s.windows[k] = win
+
+	if k == s.active {
+		if sample.Success {
+			s.consecFails = 0
+		} else {
+			s.consecFails++
+		}
+	}
+}
+
+func (s *Scheduler) checkFailover() {
+	s.mu.Lock()
+	if !s.auto || s.onFailover == nil || s.active == (key{}) {
+		s.mu.Unlock()
+		return
+	}
+	activeScore := s.scoreLocked(s.active)
+	degraded := s.consecFails >= s.FailThreshold || (activeScore.Samples > 0 && activeScore.Value < s.ScoreFloor)
+	if !degraded {
+		s.mu.Unlock()
+		return
+	}
+
+	best, bestScore := s.bestAlternativeLocked(s.active)
+	s.mu.Unlock()
+
+	if best == nil {
+		return
+	}
+	reason := fmt.Sprintf("active score %.1f below floor %.1f (best alternative %.1f)", activeScore.Value, s.ScoreFloor, bestScore.Value)
+	if s.consecFails >= s.FailThreshold {
+		reason = fmt.Sprintf("%d consecutive probe failures", s.consecFails)
+	}
+	s.onFailover(best, reason)
+}
+
+func (s *Scheduler) bestAlternativeLocked(exclude key) (*superray.Server, Score) {
+	var best *superray.Server
+	var bestScore Score
+	for _, server := range s.servers {
+		k := keyOf(server)
+		if k == exclude {
+			continue
+		}
+		sc := s.scoreLocked(k)
+		if sc.Samples == 0 {
+			continue
+		}
+		if best == nil || sc.Value > bestScore.Value {
+			best = server
+			bestScore = sc
+		}
+	}
+	return best, bestScore
+}
+
+// LastSuccess returns the timestamp of the most recent successful probe
+// for server, analogous to a WireGuard "last handshake".
+func (s *Scheduler) LastSuccess(server *superray.Server) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := s.windows[keyOf(server)]
+	for i := len(samples) - 1; i >= 0; i-- {
+		if samples[i].Success {
+			return samples[i].Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Score returns the current rolling-window score for server.
+func (s *Scheduler) Score(server *superray.Server) Score {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.scoreLocked(keyOf(server))
+}
+
+func (s *Scheduler) scoreLocked(k key) Score {
+	samples := s.windows[k]
+	if len(samples) == 0 {
+		return Score{}
+	}
+
+	var rtts []float64
+	var successes int
+	for _, sm := range samples {
+		if sm.Success {
+			successes++
+			rtts = append(rtts, float64(sm.HTTPRTTMs))
+		}
+	}
+
+	sc := Score{Samples: len(samples), SuccessRate: float64(successes) / float64(len(samples))}
+	if len(rtts) > 0 {
+		sc.MedianRTTMs = median(rtts)
+		sc.JitterMs = stdev(rtts)
+	}
+
+	// Value rewards high success rate and low/stable latency; weights are
+	// tuned so a single slow-but-reliable server still beats a fast but
+	// flaky one.
+	sc.Value = sc.SuccessRate*100 - sc.MedianRTTMs/10 - sc.JitterMs/5
+	return sc
+}
+
+func median(vals []float64) float64 {
+	sorted := append([]float64(nil), vals...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func stdev(vals []float64) float64 {
+	if len(vals) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range vals {
+		mean += v
+	}
+	mean /= float64(len(vals))
+
+	var sumSq float64
+	for _, v := range vals {
+		d := v - mean
+		sumSq += d * d
+	}
+	return math.Sqrt(sumSq / float64(len(vals)-1))
+}
@@ -0,0 +1,113 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// probeTimeoutMs bounds each TCP/HTTP probe.
+const probeTimeoutMs = 5000
+
+// DefaultProbe measures a server by briefly running it as a standalone
+// Xray instance (one SOCKS inbound, one outbound) and testing through
+// that, so results reflect the real user path rather than a direct
+// connection to the server. The sample counts as a success only when the
+// HTTP probe through that instance answers; TCPRTTMs is a direct connect
+// time recorded alongside it.
+func DefaultProbe(ctx context.Context, server *superray.Server) Sample {
+	sample := Sample{Time: time.Now()}
+
+	tcpRTT, err := superray.TCPPing(server.Address, server.Port, probeTimeoutMs)
+	if err != nil {
+		return sample
+	}
+	sample.TCPRTTMs = tcpRTT
+
+	ports, err := superray.GetFreePorts(1)
+	if err != nil || len(ports) == 0 {
+		return sample
+	}
+	localPort := ports[0]
+
+	config := buildProbeConfig(server, localPort)
+	instanceID, err := superray.Run(config)
+	if err != nil {
+		return sample
+	}
+	defer superray.DestroyInstance(instanceID)
+
+	select {
+	case <-ctx.Done():
+		return sample
+	case <-time.After(150 * time.Millisecond): // let the inbound bind
+	}
+
+	httpRTT, err := superray.HTTPPing(ProbeURL, "socks5://127.0.0.1:"+itoa(localPort), probeTimeoutMs)
+	if err != nil {
+		return sample // TCP worked, HTTP path degraded/blocked
+	}
+	sample.HTTPRTTMs = httpRTT
+	sample.Success = true
+	return sample
+}
+
+func buildProbeConfig(server *superray.Server, localPort int) string {
+	outbound := map[string]interface{}{
+		"protocol": server.Protocol,
+		"tag":      "probe",
+	}
+	// The Go binding already knows how to turn a share link into a full
+	// outbound; reuse it when available, falling back to a bare outbound
+	// of the server's protocol with no settings. If Xray rejects that,
+	// the probe stops after the TCP ping and the sample carries only
+	// TCPRTTMs.
+	if server.Link != "" {
+		if outJSON, err := superray.ShareLinkToXrayConfig(server.Link); err == nil {
+			var parsed map[string]interface{}
+			if json.Unmarshal([]byte(outJSON), &parsed) == nil {
+				outbound = parsed
+			}
+		}
+	}
+
+	config := map[string]interface{}{
+		"log": map[string]interface{}{"loglevel": "warning"},
+		"inbounds": []interface{}{
+			map[string]interface{}{
+				"tag":      "socks-probe",
+				"protocol": "socks",
+				"listen":   "127.0.0.1",
+				"port":     localPort,
+				"settings": map[string]interface{}{"udp": false},
+			},
+		},
+		"outbounds": []interface{}{outbound},
+	}
+	data, _ := json.Marshal(config)
+	return string(data)
+}
+
+func itoa(n int) string {
+	if n == 0 {
+		return "0"
+	}
+	var buf [8]byte
+	i := len(buf)
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	for n > 0 {
+		i--
+		buf[i] = byte('0' + n%10)
+		n /= 10
+	}
+	if neg {
+		i--
+		buf[i] = '-'
+	}
+	return string(buf[i:])
+}
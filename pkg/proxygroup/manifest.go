@@ -0,0 +1,74 @@
+package proxygroup
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"superray-tui/pkg/superray"
+)
+
+type manifestGroup struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	ProbeURL string   `yaml:"probe_url"`
+	Interval string   `yaml:"interval"`
+	Members  []string `yaml:"members"`
+}
+
+type manifest struct {
+	Groups []manifestGroup `yaml:"groups"`
+}
+
+// LoadManifest reads a YAML manifest describing proxy groups (referencing
+// member servers by Server.Name) and resolves it against the currently
+// loaded server list, since servers only become known after a
+// subscription is loaded. Groups naming a server not present in servers
+// are rejected rather than silently dropped.
+func LoadManifest(path string, servers []*superray.Server) (*Manager, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("proxygroup: read manifest: %w", err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("proxygroup: parse manifest: %w", err)
+	}
+
+	byName := make(map[string]*superray.Server, len(servers))
+	for _, s := range servers {
+		byName[s.Name] = s
+	}
+
+	mgr := NewManager()
+	for _, mg := range m.Groups {
+		if mg.Name == "" {
+			return nil, fmt.Errorf("proxygroup: manifest has a group with no name")
+		}
+		members := make([]*superray.Server, 0, len(mg.Members))
+		for _, name := range mg.Members {
+			s, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("proxygroup: group %q references unknown server %q", mg.Name, name)
+			}
+			members = append(members, s)
+		}
+
+		g := NewGroup(mg.Name, GroupType(mg.Type), members)
+		if mg.ProbeURL != "" {
+			g.ProbeURL = mg.ProbeURL
+		}
+		if mg.Interval != "" {
+			d, err := time.ParseDuration(mg.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("proxygroup: group %q: bad interval %q: %w", mg.Name, mg.Interval, err)
+			}
+			g.Interval = d
+		}
+		mgr.AddGroup(g)
+	}
+	return mgr, nil
+}
@@ -0,0 +1,50 @@
+package proxygroup
+
+import (
+	"context"
+
+	"superray-tui/pkg/superray"
+)
+
+// DefaultProbe measures latency to each member via the existing
+// BatchLatencyTest C bridge (shared with the server-list "Test Latency"
+// action), so group probing and manual latency tests report consistent
+// numbers. probeURL is accepted for interface symmetry with healthcheck's
+// DefaultProbe; BatchLatencyTest itself always measures a raw TCP
+// handshake rather than fetching a URL.
+func DefaultProbe(ctx context.Context, members []*superray.Server, probeURL string) map[int]int64 {
+	results := make(map[int]int64, len(members))
+	if len(members) == 0 {
+		return results
+	}
+
+	serverList := make([]map[string]interface{}, len(members))
+	for i, s := range members {
+		serverList[i] = map[string]interface{}{
+			"address": s.Address,
+			"port":    s.Port,
+			"name":    s.Name,
+		}
+	}
+
+	latencies, err := superray.BatchLatencyTest(serverList, 10, 1, 5000)
+	if err != nil {
+		for i := range members {
+			results[i] = -1
+		}
+		return results
+	}
+
+	for i, s := range members {
+		results[i] = -1
+		for _, r := range latencies {
+			if r.Address == s.Address && r.Port == s.Port {
+				if r.Success {
+					results[i] = int64(r.Latency)
+				}
+				break
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,256 @@
+// Package proxygroup implements Clash-style outbound selector groups
+// (url-test, fallback, load-balance, select) on top of a server list, so
+// a connection can fail over or load-balance across several servers
+// instead of buildConfig always emitting a single "proxy" outbound.
+package proxygroup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// GroupType selects how a Group picks its active member(s).
+type GroupType string
+
+const (
+	TypeURLTest     GroupType = "url-test"
+	TypeFallback    GroupType = "fallback"
+	TypeLoadBalance GroupType = "load-balance"
+	TypeSelect      GroupType = "select"
+)
+
+// defaultProbeURL mirrors the health scheduler's probe target so both
+// subsystems report comparable latency numbers.
+const defaultProbeURL = "http://cp.cloudflare.com/generate_204"
+
+// Group is a named set of member servers plus the policy used to pick
+// which of them carries traffic.
+type Group struct {
+	Name     string
+	Type     GroupType
+	Members  []*superray.Server
+	ProbeURL string
+	Interval time.Duration
+
+	mu      sync.Mutex
+	latency map[int]int64 // member index -> round-trip ms, -1 on failure
+	active  int            // member index currently in effect
+}
+
+// NewGroup builds a Group with ProbeURL set to defaultProbeURL and
+// Interval to 5 minutes. active defaults to member 0 until a
+// latency test or, for TypeSelect, an explicit SetActive runs.
+func NewGroup(name string, typ GroupType, members []*superray.Server) *Group {
+	return &Group{
+		Name:     name,
+		Type:     typ,
+		Members:  members,
+		ProbeURL: defaultProbeURL,
+		Interval: 5 * time.Minute,
+		latency:  make(map[int]int64),
+	}
+}
+
+// Active returns the currently-selected member and its index. ok is false
+// if the group has no members.
+func (g *Group) Active() (*superray.Server, int, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.Members) == 0 {
+		return nil, -1, false
+	}
+	idx := g.active
+	if idx < 0 || idx >= len(g.Members) {
+		idx = 0
+	}
+	return g.Members[idx], idx, true
+}
+
+// SetActive manually selects a member by index. Intended for TypeSelect
+// groups, where the user rather than a latency test picks the member, but
+// any group type accepts it (e.g. to force a fallback's choice).
+func (g *Group) SetActive(idx int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if idx < 0 || idx >= len(g.Members) {
+		return false
+	}
+	g.active = idx
+	return true
+}
+
+// Latencies returns a snapshot of the last probe's per-member results.
+func (g *Group) Latencies() map[int]int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	out := make(map[int]int64, len(g.latency))
+	for k, v := range g.latency {
+		out[k] = v
+	}
+	return out
+}
+
+// recordLatencies stores a probe result and, for non-select group types,
+// re-picks the active member according to Type.
+func (g *Group) recordLatencies(results map[int]int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.latency = results
+
+	if g.Type == TypeSelect {
+		return
+	}
+
+	switch g.Type {
+	case TypeURLTest:
+		// Lowest latency among healthy members wins.
+		best, bestLatency := -1, int64(-1)
+		for idx, ms := range results {
+			if ms < 0 {
+				continue
+			}
+			if best == -1 || ms < bestLatency {
+				best, bestLatency = idx, ms
+			}
+		}
+		if best != -1 {
+			g.active = best
+		}
+	case TypeFallback:
+		// First healthy member, in declared order, wins.
+		for idx := range g.Members {
+			if ms, ok := results[idx]; ok && ms >= 0 {
+				g.active = idx
+				break
+			}
+		}
+	case TypeLoadBalance:
+		// Round-robin across healthy members.
+		healthy := make([]int, 0, len(g.Members))
+		for idx := range g.Members {
+			if ms, ok := results[idx]; ok && ms >= 0 {
+				healthy = append(healthy, idx)
+			}
+		}
+		if len(healthy) > 0 {
+			pos := 0
+			for i, idx := range healthy {
+				if idx == g.active {
+					pos = i
+					break
+				}
+			}
+			g.active = healthy[(pos+1)%len(healthy)]
+		}
+	}
+}
+
+// ProbeFunc measures round-trip latency (ms) to each member, returning -1
+// for members that failed. It is a field on Manager so tests (and
+// DefaultProbe itself) can be swapped independently of group logic.
+type ProbeFunc func(ctx context.Context, members []*superray.Server, probeURL string) map[int]int64
+
+// Manager owns a set of Groups and periodically re-probes them.
+type Manager struct {
+	mu     sync.Mutex
+	groups map[string]*Group
+
+	Probe ProbeFunc
+}
+
+// NewManager builds an empty Manager using DefaultProbe.
+func NewManager() *Manager {
+	return &Manager{groups: make(map[string]*Group), Probe: DefaultProbe}
+}
+
+// AddGroup registers g, replacing any existing group with the same name.
+func (m *Manager) AddGroup(g *Group) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.groups[g.Name] = g
+}
+
+// Group returns the named group, if registered.
+func (m *Manager) Group(name string) (*Group, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g, ok := m.groups[name]
+	return g, ok
+}
+
+// Groups returns every registered group in no particular order.
+func (m *Manager) Groups() []*Group {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]*Group, 0, len(m.groups))
+	for _, g := range m.groups {
+		out = append(out, g)
+	}
+	return out
+}
+
+// Run probes every group on its own Interval until ctx is cancelled.
+func (m *Manager) Run(ctx context.Context) {
+	tickers := make(map[string]*time.Ticker)
+	defer func() {
+		for _, t := range tickers {
+			t.Stop()
+		}
+	}()
+
+	for _, g := range m.Groups() {
+		tickers[g.Name] = time.NewTicker(g.Interval)
+		go m.probeOnce(ctx, g) // seed an immediate first probe
+	}
+
+	cases := make(chan *Group)
+	for name, t := range tickers {
+		g, _ := m.Group(name)
+		t := t
+		go func(g *Group, t *time.Ticker) {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-t.C:
+					select {
+					case cases <- g:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}(g, t)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case g := <-cases:
+			m.probeOnce(ctx, g)
+		}
+	}
+}
+
+func (m *Manager) probeOnce(ctx context.Context, g *Group) {
+	if g.Type == TypeSelect || len(g.Members) == 0 {
+		return
+	}
+	results := m.Probe(ctx, g.Members, g.ProbeURL)
+	g.recordLatencies(results)
+}
+
+// Retest immediately re-probes the named group, regardless of its
+// Interval, e.g. in response to a TUI "re-test" action.
+func (m *Manager) Retest(ctx context.Context, name string) bool {
+	g, ok := m.Group(name)
+	if !ok {
+		return false
+	}
+	m.probeOnce(ctx, g)
+	return true
+}
@@ -0,0 +1,66 @@
+package proxygroup
+
+import "fmt"
+
+// memberTag is the Xray outbound tag synthesized for one group member.
+func memberTag(groupName string, idx int) string {
+	return fmt.Sprintf("%s-%d", groupName, idx)
+}
+
+// BuildOutbounds returns one Xray outbound per member of g, tagged so a
+// balancer (or, for TypeSelect, a routing rule) can reference them. build
+// is the caller's existing per-server outbound builder (main.go's
+// buildOutboundFromServer), kept as a parameter so this package doesn't
+// need to depend on main's config-shape conventions.
+func BuildOutbounds(g *Group, build func(idx int, tag string) map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(g.Members))
+	for i := range g.Members {
+		out[i] = build(i, memberTag(g.Name, i))
+	}
+	return out
+}
+
+// RouteTarget describes how routing rules should reference a group: for
+// TypeSelect it's a fixed outboundTag (the active member); for the other
+// types it's a balancerTag paired with a Balancer config entry that must
+// also be added to routing.balancers.
+type RouteTarget struct {
+	OutboundTag string
+	BalancerTag string
+	Balancer    map[string]interface{}
+}
+
+// xrayStrategy maps a GroupType to the Xray balancer strategy that best
+// approximates it; TypeSelect has no balancer (handled by RouteTarget
+// returning a fixed OutboundTag instead).
+var xrayStrategy = map[GroupType]string{
+	TypeURLTest:     "leastPing",
+	TypeFallback:    "leastPing",
+	TypeLoadBalance: "random",
+}
+
+// BuildRouteTarget synthesizes the balancer (or, for TypeSelect, the
+// chosen member's direct tag) that routing rules for g should target.
+func BuildRouteTarget(g *Group) RouteTarget {
+	if g.Type == TypeSelect {
+		_, idx, ok := g.Active()
+		if !ok {
+			return RouteTarget{}
+		}
+		return RouteTarget{OutboundTag: memberTag(g.Name, idx)}
+	}
+
+	selector := make([]string, len(g.Members))
+	for i := range g.Members {
+		selector[i] = memberTag(g.Name, i)
+	}
+	balancerTag := g.Name + "-balancer"
+	return RouteTarget{
+		BalancerTag: balancerTag,
+		Balancer: map[string]interface{}{
+			"tag":      balancerTag,
+			"selector": selector,
+			"strategy": map[string]interface{}{"type": xrayStrategy[g.Type]},
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package ruleset
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// geoAssetURLs are the canonical upstream locations for Xray's GEOSITE and
+// GEOIP asset files, used to bootstrap AssetDir when they're missing.
+var geoAssetURLs = map[string]string{
+	"geosite.dat": "https://github.com/v2fly/domain-list-community/releases/latest/download/dlc.dat",
+	"geoip.dat":   "https://github.com/v2fly/geoip/releases/latest/download/geoip.dat",
+}
+
+// EnsureGeoAssets downloads geosite.dat/geoip.dat into AssetDir for any
+// that don't already exist there, so GEOSITE:/GEOIP: rules resolve.
+func (m *Manager) EnsureGeoAssets(ctx context.Context) error {
+	if m.AssetDir == "" {
+		return fmt.Errorf("ruleset: asset dir not set")
+	}
+	if err := os.MkdirAll(m.AssetDir, 0755); err != nil {
+		return err
+	}
+
+	for name, url := range geoAssetURLs {
+		path := filepath.Join(m.AssetDir, name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		data, err := fetchURL(ctx, m.client, url)
+		if err != nil {
+			return fmt.Errorf("ruleset: download %s: %w", name, err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("ruleset: write %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package ruleset
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestProvider is one entry in a provider manifest file.
+type manifestProvider struct {
+	Name     string `yaml:"name"`
+	Path     string `yaml:"path"`
+	URL      string `yaml:"url"`
+	Action   string `yaml:"action"`
+	Interval string `yaml:"interval"`
+}
+
+type manifest struct {
+	Providers []manifestProvider `yaml:"providers"`
+}
+
+// LoadManifest reads a YAML manifest describing named rule providers
+// (local file or remote URL, each with its own RuleAction and optional
+// refresh interval), builds and loads each one, and returns a Manager
+// ready to use. Relative provider paths are resolved against assetDir.
+func LoadManifest(ctx context.Context, manifestPath, assetDir string) (*Manager, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("ruleset: read manifest: %w", err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("ruleset: parse manifest: %w", err)
+	}
+
+	mgr := NewManager(assetDir)
+	for _, mp := range m.Providers {
+		if mp.Name == "" {
+			return nil, fmt.Errorf("ruleset: manifest has a provider with no name")
+		}
+		action := RuleAction(mp.Action)
+		if action == "" {
+			action = ActionProxy
+		}
+		var interval time.Duration
+		if mp.Interval != "" {
+			interval, err = time.ParseDuration(mp.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("ruleset: provider %q: bad interval %q: %w", mp.Name, mp.Interval, err)
+			}
+		}
+
+		p := &Provider{
+			Name:     mp.Name,
+			Path:     mp.Path,
+			URL:      mp.URL,
+			Action:   action,
+			Interval: interval,
+		}
+		if err := mgr.AddProvider(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	return mgr, nil
+}
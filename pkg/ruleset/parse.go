@@ -0,0 +1,80 @@
+package ruleset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parsePayload extracts the list of rule lines from a provider file. It
+// accepts three shapes: a Clash-style rule-provider YAML document with a
+// top-level "payload" list, a bare JSON array of strings, or a plain-text
+// file with one "TYPE,VALUE[,ACTION]" rule per line (blank lines and '#'
+// comments ignored).
+func parsePayload(data []byte) ([]string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	if trimmed[0] == '[' {
+		var lines []string
+		if err := json.Unmarshal(trimmed, &lines); err == nil {
+			return lines, nil
+		}
+	}
+
+	var doc struct {
+		Payload []string `yaml:"payload"`
+	}
+	if err := yaml.Unmarshal(trimmed, &doc); err == nil && len(doc.Payload) > 0 {
+		return doc.Payload, nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseRuleLines parses "TYPE,VALUE[,ACTION]" lines. When a line omits
+// ACTION, defaultAction (the provider's configured Action) is used.
+func parseRuleLines(lines []string, defaultAction RuleAction) ([]Rule, error) {
+	var rules []Rule
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(line, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("malformed rule line %q", line)
+		}
+
+		action := defaultAction
+		if len(parts) >= 3 && parts[2] != "" {
+			action = RuleAction(strings.ToLower(parts[2]))
+		}
+		if action == "" {
+			action = ActionProxy
+		}
+
+		rules = append(rules, Rule{
+			Type:   RuleType(strings.ToUpper(parts[0])),
+			Value:  parts[1],
+			Action: action,
+		})
+	}
+	return rules, nil
+}
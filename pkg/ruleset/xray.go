@@ -0,0 +1,92 @@
+package ruleset
+
+// actionOrder fixes the output order of translated rules so config
+// generation is deterministic across runs (map iteration is not).
+var actionOrder = []RuleAction{ActionProxy, ActionDirect, ActionBlock}
+
+// ToXrayRules translates a merged rule set into Xray "field" routing
+// rule objects, grouped by (matcher kind, action) so each action gets at
+// most one rule object per matcher kind (domain, ip, protocol,
+// inboundTag). outboundTag maps a RuleAction to the Xray outbound tag it
+// should route to (e.g. proxy->"proxy", direct->"direct",
+// block->"block"). PROCESS-NAME rules are recorded by callers wanting
+// them (e.g. a future sing-box backend) but are skipped here: Xray-core
+// has no process-based routing hook.
+func ToXrayRules(rules []Rule, outboundTag map[RuleAction]string) []map[string]interface{} {
+	type group struct {
+		domains     []string
+		ips         []string
+		protocols   []string
+		inboundTags []string
+	}
+	byAction := make(map[RuleAction]*group)
+
+	for _, r := range rules {
+		g, ok := byAction[r.Action]
+		if !ok {
+			g = &group{}
+			byAction[r.Action] = g
+		}
+		switch r.Type {
+		case TypeDomain:
+			g.domains = append(g.domains, "full:"+r.Value)
+		case TypeDomainSuffix:
+			g.domains = append(g.domains, "domain:"+r.Value)
+		case TypeDomainKeyword:
+			g.domains = append(g.domains, r.Value)
+		case TypeGeoSite:
+			g.domains = append(g.domains, "geosite:"+r.Value)
+		case TypeIPCIDR:
+			g.ips = append(g.ips, r.Value)
+		case TypeGeoIP:
+			g.ips = append(g.ips, "geoip:"+r.Value)
+		case TypeProtocol:
+			g.protocols = append(g.protocols, r.Value)
+		case TypeInboundTag:
+			g.inboundTags = append(g.inboundTags, r.Value)
+		case TypeProcessName:
+			// Not translatable; see doc comment above.
+		}
+	}
+
+	var out []map[string]interface{}
+	for _, action := range actionOrder {
+		g, ok := byAction[action]
+		if !ok {
+			continue
+		}
+		tag := outboundTag[action]
+		if tag == "" {
+			continue
+		}
+		if len(g.domains) > 0 {
+			out = append(out, map[string]interface{}{
+				"type":        "field",
+				"domain":      g.domains,
+				"outboundTag": tag,
+			})
+		}
+		if len(g.ips) > 0 {
+			out = append(out, map[string]interface{}{
+				"type":        "field",
+				"ip":          g.ips,
+				"outboundTag": tag,
+			})
+		}
+		if len(g.protocols) > 0 {
+			out = append(out, map[string]interface{}{
+				"type":        "field",
+				"protocol":    g.protocols,
+				"outboundTag": tag,
+			})
+		}
+		if len(g.inboundTags) > 0 {
+			out = append(out, map[string]interface{}{
+				"type":        "field",
+				"inboundTag":  g.inboundTags,
+				"outboundTag": tag,
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package gonet
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// deadlineTimer gives Read/Write a cancel channel that both a
+// SetDeadline call and time.AfterFunc can close, so a blocking
+// superray.CallbackTUNRead/Write call (which has no ctx of its own)
+// returns promptly when its deadline passes instead of hanging until the
+// underlying handle itself times out. Mirrors the deadlineTimer gVisor's
+// own gonet package uses for the same reason.
+type deadlineTimer struct {
+	mu       sync.Mutex
+	timer    *time.Timer
+	expired  chan struct{} // closed when the current deadline fires
+	deadline time.Time     // zero means no deadline
+}
+
+func newDeadlineTimer() *deadlineTimer {
+	return &deadlineTimer{expired: make(chan struct{})}
+}
+
+// setDeadline arms (or disarms, for a zero t) the timer, replacing
+// whatever channel/timer a previous call installed.
+func (d *deadlineTimer) setDeadline(t time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.deadline = t
+	d.expired = make(chan struct{})
+
+	if t.IsZero() {
+		d.timer = nil
+		return nil
+	}
+	if !t.After(time.Now()) {
+		close(d.expired)
+		return nil
+	}
+
+	expired := d.expired
+	d.timer = time.AfterFunc(time.Until(t), func() { close(expired) })
+	return nil
+}
+
+// channel returns the cancel channel for the deadline in effect when it
+// was called, so a concurrent setDeadline doesn't change which channel an
+// in-flight Read/Write is already selecting on.
+func (d *deadlineTimer) channel() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.expired
+}
+
+// ErrTimeout is returned by Read/Write once their deadline has passed.
+var ErrTimeout = errors.New("gonet: i/o timeout")
+
+// conn is the net.Conn returned by Dial/DialContext/Listener.Accept,
+// backed by a superray CallbackTUN connection handle.
+type conn struct {
+	handle string
+	remote addr
+
+	readTimer  *deadlineTimer
+	writeTimer *deadlineTimer
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func newConn(handle, remoteAddr string) *conn {
+	return &conn{
+		handle:     handle,
+		remote:     addr(remoteAddr),
+		readTimer:  newDeadlineTimer(),
+		writeTimer: newDeadlineTimer(),
+	}
+}
+
+// Read and Write each run the blocking CallbackTUN call on its own
+// goroutine and race it against the relevant deadline's cancel channel,
+// since CallbackTUNRead/Write themselves have no way to be interrupted
+// mid-call. On a timeout that goroutine is left to finish on its own -
+// it exits (and its result is discarded) once the call returns, which
+// for a stalled peer means not until Close() tears down the handle.
+func (c *conn) Read(p []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := superray.CallbackTUNRead(c.handle, p)
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-c.readTimer.channel():
+		return 0, ErrTimeout
+	}
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := superray.CallbackTUNWrite(c.handle, p)
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-c.writeTimer.channel():
+		return 0, ErrTimeout
+	}
+}
+
+func (c *conn) Close() error {
+	c.closeOnce.Do(func() { c.closeErr = superray.CallbackTUNClose(c.handle) })
+	return c.closeErr
+}
+
+func (c *conn) LocalAddr() net.Addr  { return addr(c.handle) }
+func (c *conn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *conn) SetDeadline(t time.Time) error {
+	if err := c.readTimer.setDeadline(t); err != nil {
+		return err
+	}
+	return c.writeTimer.setDeadline(t)
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error  { return c.readTimer.setDeadline(t) }
+func (c *conn) SetWriteDeadline(t time.Time) error { return c.writeTimer.setDeadline(t) }
@@ -0,0 +1,88 @@
+// Package gonet adapts an Xray instance's CallbackTUN dialer into
+// standard net.Conn/net.Listener values, the same shape gVisor's own
+// gonet adapter gives a tcpip.Stack. It lets ordinary Go code - an
+// http.Client, a gRPC dialer, an SSH client - use an Xray instance as
+// its transport without going through a local SOCKS/HTTP inbound port.
+package gonet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// Dial opens a connection to address (network is "tcp" or "udp") through
+// instance tag's outboundTag, as set up by
+// superray.CreateCallbackTUNWithDialer + superray.StartCallbackTUN.
+func Dial(tag, network, address string) (net.Conn, error) {
+	return DialContext(context.Background(), tag, network, address)
+}
+
+// DialContext is Dial with ctx cancellation/deadline honored: if ctx is
+// done before the dial completes, the in-flight CallbackTUNDial call is
+// abandoned (its handle, if it arrives late, is closed immediately
+// rather than leaked).
+func DialContext(ctx context.Context, tag, network, address string) (net.Conn, error) {
+	type result struct {
+		handle string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		handle, err := superray.CallbackTUNDial(tag, network, address)
+		done <- result{handle, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, fmt.Errorf("gonet: dial %s %s via %s: %w", network, address, tag, r.err)
+		}
+		return newConn(r.handle, address), nil
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.err == nil {
+				superray.CallbackTUNClose(r.handle)
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// Listen accepts connections Xray instance instanceID forwards to
+// inboundTag.
+func Listen(instanceID, inboundTag string) (net.Listener, error) {
+	handle, err := superray.CallbackTUNListen(instanceID, inboundTag)
+	if err != nil {
+		return nil, fmt.Errorf("gonet: listen on %s/%s: %w", instanceID, inboundTag, err)
+	}
+	return &listener{handle: handle, addr: addr(instanceID + "/" + inboundTag)}, nil
+}
+
+type listener struct {
+	handle string
+	addr   addr
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	connHandle, remoteAddr, err := superray.CallbackTUNAccept(l.handle)
+	if err != nil {
+		return nil, err
+	}
+	return newConn(connHandle, remoteAddr), nil
+}
+
+func (l *listener) Close() error   { return superray.CallbackTUNClose(l.handle) }
+func (l *listener) Addr() net.Addr { return l.addr }
+
+// addr is a minimal net.Addr for handles that don't carry a structured
+// host/port (the CallbackTUN bridge identifies endpoints by tag, not by
+// a dialable address).
+type addr string
+
+func (a addr) Network() string { return "callbacktun" }
+func (a addr) String() string  { return string(a) }
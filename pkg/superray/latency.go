@@ -0,0 +1,259 @@
+package superray
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ProbeMode selects what BatchLatencyTestCtx measures for each server,
+// in increasing order of how much of the real connection path it
+// exercises. TCPPing and BatchLatencyTest (in superray.go) measure a raw
+// TCP handshake through the cgo bridge, and HTTPPing needs a proxy
+// address to send its request through; ProbeMode lets a
+// caller ask for TLS or application-layer signal instead, without
+// needing a running Xray instance to proxy through.
+type ProbeMode int
+
+const (
+	// ProbeTCP times a bare TCP connect, same signal as BatchLatencyTest.
+	ProbeTCP ProbeMode = iota
+	// ProbeTLS times a TCP connect plus TLS handshake.
+	ProbeTLS
+	// ProbeHTTPGet times a full HTTPS GET against opts.ProbeURL,
+	// reporting the TLS handshake and time-to-first-byte separately.
+	ProbeHTTPGet
+	// ProbeDoH is ProbeHTTPGet with the Accept header a DNS-over-HTTPS
+	// resolver expects, so a caller probing candidate servers before
+	// pointing dnsresolver at them sees the same shape of request it
+	// will actually make.
+	ProbeDoH
+)
+
+// SortMode controls how BatchLatencyTestCtx orders the results it emits.
+type SortMode int
+
+const (
+	// SortNone emits each result as soon as its probe finishes.
+	SortNone SortMode = iota
+	// SortBest buffers every result until all probes finish (or ctx is
+	// cancelled), then emits them successful-first, fastest-first.
+	SortBest
+)
+
+// DefaultConcurrency bounds BatchLatencyTestCtx when BatchOptions.Concurrency
+// is unset.
+const DefaultConcurrency = 10
+
+// DefaultProbeTimeout bounds a single probe attempt when
+// BatchOptions.Timeout is unset.
+const DefaultProbeTimeout = 5 * time.Second
+
+// BatchOptions configures BatchLatencyTestCtx.
+type BatchOptions struct {
+	Mode     ProbeMode
+	ProbeURL string // required for ProbeHTTPGet/ProbeDoH; ignored otherwise
+
+	Concurrency int           // max probes in flight; DefaultConcurrency if <= 0
+	Retries     int           // additional attempts after a failed probe
+	Timeout     time.Duration // per-attempt budget; DefaultProbeTimeout if <= 0
+	Jitter      time.Duration // random 0..Jitter delay before each retry, to spread out a burst
+
+	Sort  SortMode
+	Limit int // with SortBest, keep only the best Limit results; 0 keeps all
+}
+
+// BatchLatencyTestCtx probes servers concurrently per opts and streams a
+// LatencyResult for each over the returned channel, which is closed once
+// every probe has completed or ctx is cancelled. Unlike BatchLatencyTest
+// (a blocking cgo call), it runs entirely in Go, so in-flight probes stop
+// promptly when ctx is cancelled instead of running to their own timeout.
+func BatchLatencyTestCtx(ctx context.Context, servers []Server, opts BatchOptions) (<-chan LatencyResult, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("superray: no servers to test")
+	}
+	if (opts.Mode == ProbeHTTPGet || opts.Mode == ProbeDoH) && opts.ProbeURL == "" {
+		return nil, fmt.Errorf("superray: ProbeURL required for mode %d", opts.Mode)
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	out := make(chan LatencyResult, len(servers))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	var collectMu sync.Mutex
+	var collected []LatencyResult
+
+	for _, s := range servers {
+		s := s
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			r := probeWithRetries(ctx, s, opts)
+			if opts.Sort == SortBest {
+				collectMu.Lock()
+				collected = append(collected, r)
+				collectMu.Unlock()
+				return
+			}
+			out <- r
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		if opts.Sort == SortBest {
+			sort.SliceStable(collected, func(i, j int) bool {
+				if collected[i].Success != collected[j].Success {
+					return collected[i].Success
+				}
+				return collected[i].Latency < collected[j].Latency
+			})
+			if opts.Limit > 0 && opts.Limit < len(collected) {
+				collected = collected[:opts.Limit]
+			}
+			for _, r := range collected {
+				out <- r
+			}
+		}
+		close(out)
+	}()
+
+	return out, nil
+}
+
+// probeWithRetries runs one probe attempt, and up to opts.Retries more
+// after a jittered delay, stopping early on success or ctx cancellation.
+func probeWithRetries(ctx context.Context, s Server, opts BatchOptions) LatencyResult {
+	attempts := opts.Retries + 1
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 && opts.Jitter > 0 {
+			select {
+			case <-time.After(time.Duration(rand.Int63n(int64(opts.Jitter) + 1))):
+			case <-ctx.Done():
+				return LatencyResult{Address: s.Address, Port: s.Port, Name: s.Name, Latency: -1, Error: ctx.Err().Error()}
+			}
+		}
+		r := probeOnce(ctx, s, opts)
+		if r.Success || ctx.Err() != nil {
+			return r
+		}
+		if attempt == attempts-1 {
+			return r
+		}
+	}
+	return LatencyResult{Address: s.Address, Port: s.Port, Name: s.Name, Latency: -1}
+}
+
+// probeOnce performs a single probe attempt per opts.Mode, bounded by
+// opts.Timeout.
+func probeOnce(ctx context.Context, s Server, opts BatchOptions) LatencyResult {
+	result := LatencyResult{Address: s.Address, Port: s.Port, Name: s.Name, Latency: -1}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+	pctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var ms, tlsMs, ttfbMs int
+	var err error
+	switch opts.Mode {
+	case ProbeTLS:
+		ms, err = tlsHandshakeProbe(pctx, s.Address, s.Port)
+	case ProbeHTTPGet, ProbeDoH:
+		ms, tlsMs, ttfbMs, err = httpsProbe(pctx, opts.ProbeURL, opts.Mode == ProbeDoH)
+	default:
+		ms, err = tcpConnectProbe(pctx, s.Address, s.Port)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+	result.Latency = ms
+	result.TLSHandshakeMs = tlsMs
+	result.TTFBMs = ttfbMs
+	return result
+}
+
+func tcpConnectProbe(ctx context.Context, address string, port int) (int, error) {
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err != nil {
+		return 0, err
+	}
+	conn.Close()
+	return int(time.Since(start).Milliseconds()), nil
+}
+
+func tlsHandshakeProbe(ctx context.Context, address string, port int) (int, error) {
+	start := time.Now()
+	dialer := tls.Dialer{Config: &tls.Config{MinVersion: tls.VersionTLS12, ServerName: address}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(port)))
+	if err != nil {
+		return 0, err
+	}
+	conn.Close()
+	return int(time.Since(start).Milliseconds()), nil
+}
+
+// httpsProbe fetches url, reporting total, TLS-handshake, and
+// time-to-first-byte durations (ms) via httptrace. doh sets the Accept
+// header a DNS-over-HTTPS resolver would send, without building an
+// actual DNS query - this is a latency signal, not a resolver.
+func httpsProbe(ctx context.Context, url string, doh bool) (totalMs, tlsMs, ttfbMs int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if doh {
+		req.Header.Set("Accept", "application/dns-message")
+	}
+
+	var tlsStart, tlsDone, firstByte time.Time
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart:    func() { tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { tlsDone = time.Now() },
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if !tlsStart.IsZero() && !tlsDone.IsZero() {
+		tlsMs = int(tlsDone.Sub(tlsStart).Milliseconds())
+	}
+	if !firstByte.IsZero() {
+		ttfbMs = int(firstByte.Sub(start).Milliseconds())
+	}
+	return int(time.Since(start).Milliseconds()), tlsMs, ttfbMs, nil
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// latencyBucketsMs are the histogram bucket upper bounds (ms) for
+// superray_latency_ms, chosen to resolve typical proxy RTTs (tens to a
+// few hundred ms) without an excessive bucket count.
+var latencyBucketsMs = []float64{10, 25, 50, 100, 200, 400, 800, 1600, 3200}
+
+// histKey identifies one server/protocol latency series.
+type histKey struct {
+	server   string
+	protocol string
+}
+
+// histogram is a fixed-bucket-boundary histogram, same shape Prometheus
+// client libraries expose: buckets holds the per-bucket (not cumulative)
+// count, with one extra slot for +Inf.
+type histogram struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram() *histogram {
+	return &histogram{buckets: make([]uint64, len(latencyBucketsMs)+1)}
+}
+
+func (h *histogram) observe(ms float64) {
+	h.sum += ms
+	h.count++
+	for i, le := range latencyBucketsMs {
+		if ms <= le {
+			h.buckets[i]++
+			return
+		}
+	}
+	h.buckets[len(latencyBucketsMs)]++
+}
+
+// runLatencyLoop probes opts.Servers every opts.LatencyInterval with
+// superray.BatchLatencyTest and folds each result into the matching
+// server/protocol histogram, until ctx is done.
+func (r *Registry) runLatencyLoop(ctx context.Context, opts MetricsOptions) {
+	interval := opts.LatencyInterval
+	if interval <= 0 {
+		interval = DefaultLatencyInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.probeOnce(opts.Servers)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.probeOnce(opts.Servers)
+		}
+	}
+}
+
+func (r *Registry) probeOnce(servers []superray.Server) {
+	serverList := make([]map[string]interface{}, len(servers))
+	for i, s := range servers {
+		serverList[i] = map[string]interface{}{
+			"address": s.Address,
+			"port":    s.Port,
+			"name":    s.Name,
+		}
+	}
+
+	results, err := superray.BatchLatencyTest(serverList, 10, 1, 5000)
+	if err != nil {
+		return
+	}
+
+	byAddr := make(map[string]superray.Server, len(servers))
+	for _, s := range servers {
+		byAddr[addrKey(s.Address, s.Port)] = s
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, res := range results {
+		if !res.Success {
+			continue
+		}
+		s, ok := byAddr[addrKey(res.Address, res.Port)]
+		if !ok {
+			continue
+		}
+		name := s.Name
+		if name == "" {
+			name = s.Address
+		}
+		key := histKey{server: name, protocol: s.Protocol}
+		h, ok := r.hist[key]
+		if !ok {
+			h = newHistogram()
+			r.hist[key] = h
+		}
+		h.observe(float64(res.Latency))
+	}
+}
+
+// addrKey joins address+port into the map key both probeOnce call sites
+// use to correlate a BatchLatencyTest result back to its source Server.
+func addrKey(address string, port int) string {
+	return address + ":" + strconv.Itoa(port)
+}
@@ -0,0 +1,198 @@
+// Package metrics scrapes an Xray instance's traffic stats and latency on
+// an interval and exposes them as Prometheus text-format metrics (and,
+// optionally, pushes the same snapshot to an OTLP collector), building on
+// superray.GetXrayStats/GetCurrentSpeed/BatchLatencyTest the same way
+// pkg/healthcheck builds a quality score on top of raw probe samples.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// DefaultScrapeInterval is the traffic-stats poll cadence used when
+// MetricsOptions.ScrapeInterval is unset.
+const DefaultScrapeInterval = 15 * time.Second
+
+// DefaultLatencyInterval is the probe cadence used when
+// MetricsOptions.LatencyInterval is unset.
+const DefaultLatencyInterval = 60 * time.Second
+
+// MetricsOptions configures StartMetricsServer.
+type MetricsOptions struct {
+	// InstanceID is polled via superray.GetInstanceState to populate
+	// superray_instance_up{id=}.
+	InstanceID string
+
+	// ScrapeInterval is how often GetXrayStats/GetCurrentSpeed/
+	// GetInstanceState are polled.
+	ScrapeInterval time.Duration
+
+	// Servers, if non-empty, are probed every LatencyInterval with
+	// superray.BatchLatencyTest to feed superray_latency_ms.
+	Servers         []superray.Server
+	LatencyInterval time.Duration
+
+	// OTLP, if set, additionally pushes the current snapshot to an
+	// OTLP/HTTP collector every OTLP.PushInterval (independent of
+	// ScrapeInterval) - an alternative to scraping /metrics for
+	// deployments that centralize on OTLP instead of Prometheus.
+	OTLP *OTLPOptions
+}
+
+// StartMetricsServer starts the scrape loop (and, if opts.OTLP is set,
+// the OTLP push loop) and serves Prometheus text format at
+// http://addr/metrics. It returns once the listener is bound; the server
+// and scrape loop then run for the process's lifetime.
+func StartMetricsServer(addr string, opts MetricsOptions) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics: listen on %s: %w", addr, err)
+	}
+
+	reg := newRegistry(opts.InstanceID)
+
+	ctx := context.Background()
+	go reg.runScrapeLoop(ctx, opts)
+	if len(opts.Servers) > 0 {
+		go reg.runLatencyLoop(ctx, opts)
+	}
+	if opts.OTLP != nil {
+		go reg.runOTLPPushLoop(ctx, *opts.OTLP)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		reg.render(w)
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+
+	return nil
+}
+
+// counterKey identifies one uplink/downlink counter series.
+type counterKey struct {
+	direction string // "uplink" or "downlink"
+	scope     string // "inbound" or "outbound"
+	tag       string
+}
+
+// counterState tracks a monotonic counter across instance restarts: Xray
+// itself resets its own uplink/downlink counters to zero when an
+// instance is torn down and recreated, but a Prometheus counter must
+// never go backwards, so base absorbs every prior lifetime's final
+// value.
+type counterState struct {
+	lastRaw int64
+	base    int64
+}
+
+// addRaw folds in a freshly-scraped cumulative value and returns the
+// counter's exported (never-decreasing) total.
+func (c *counterState) addRaw(raw int64) int64 {
+	if raw < c.lastRaw {
+		c.base += c.lastRaw
+	}
+	c.lastRaw = raw
+	return c.base + raw
+}
+
+// Registry holds the current metrics snapshot, safe for concurrent use
+// by the scrape/latency/OTLP-push loops (writers) and the /metrics
+// handler (reader).
+type Registry struct {
+	mu sync.Mutex
+
+	instanceID string
+	instanceUp float64
+
+	counters   map[counterKey]*counterState
+	speedUp    float64
+	speedDown  float64
+
+	hist map[histKey]*histogram
+}
+
+func newRegistry(instanceID string) *Registry {
+	return &Registry{
+		instanceID: instanceID,
+		counters:   make(map[counterKey]*counterState),
+		hist:       make(map[histKey]*histogram),
+	}
+}
+
+// runScrapeLoop polls traffic stats and instance state every
+// opts.ScrapeInterval until ctx is done.
+func (r *Registry) runScrapeLoop(ctx context.Context, opts MetricsOptions) {
+	interval := opts.ScrapeInterval
+	if interval <= 0 {
+		interval = DefaultScrapeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	r.scrapeOnce(opts.InstanceID)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.scrapeOnce(opts.InstanceID)
+		}
+	}
+}
+
+func (r *Registry) scrapeOnce(instanceID string) {
+	stats, err := superray.GetXrayStats()
+	if err == nil && stats != nil {
+		r.applyStats(stats)
+	}
+	if speed, err := superray.GetCurrentSpeed(); err == nil && speed != nil {
+		r.mu.Lock()
+		r.speedUp = speed.UplinkRate
+		r.speedDown = speed.DownlinkRate
+		r.mu.Unlock()
+	}
+	if instanceID != "" {
+		up := 0.0
+		if state, err := superray.GetInstanceState(instanceID); err == nil && state == "running" {
+			up = 1
+		}
+		r.mu.Lock()
+		r.instanceUp = up
+		r.mu.Unlock()
+	}
+}
+
+func (r *Registry) applyStats(stats *superray.TrafficStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apply := func(scope string, byTag map[string]superray.InOutStats) {
+		for tag, s := range byTag {
+			r.counterFor(counterKey{"uplink", scope, tag}).addRaw(s.Uplink)
+			r.counterFor(counterKey{"downlink", scope, tag}).addRaw(s.Downlink)
+		}
+	}
+	apply("inbound", stats.Inbounds)
+	apply("outbound", stats.Outbounds)
+}
+
+// counterFor returns (creating if needed) the counter state for key.
+// Callers must hold r.mu.
+func (r *Registry) counterFor(key counterKey) *counterState {
+	c, ok := r.counters[key]
+	if !ok {
+		c = &counterState{}
+		r.counters[key] = c
+	}
+	return c
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OTLPOptions configures the OTLP/HTTP push alternative to scraping
+// /metrics. It's a minimal exporter - hand-rolled JSON over OTLP/HTTP's
+// metrics endpoint rather than a full go.opentelemetry.io SDK dependency
+// - covering the same counters and gauges StartMetricsServer's
+// Prometheus endpoint exposes. The superray_latency_ms histogram is not
+// pushed; it is only available from /metrics.
+type OTLPOptions struct {
+	Endpoint      string            // e.g. "http://localhost:4318/v1/metrics"
+	PushInterval  time.Duration     // DefaultOTLPPushInterval if <= 0
+	ResourceAttrs map[string]string // e.g. {"service.name": "superray-tui"}
+}
+
+// DefaultOTLPPushInterval is the push cadence used when
+// OTLPOptions.PushInterval is unset.
+const DefaultOTLPPushInterval = 15 * time.Second
+
+func (r *Registry) runOTLPPushLoop(ctx context.Context, opts OTLPOptions) {
+	interval := opts.PushInterval
+	if interval <= 0 {
+		interval = DefaultOTLPPushInterval
+	}
+	client := &http.Client{Timeout: interval}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.pushOTLP(ctx, client, opts)
+		}
+	}
+}
+
+// otlp* types are the minimal subset of the OTLP metrics JSON schema
+// (opentelemetry-proto's metrics_service.proto, JSON-mapped) this
+// exporter populates.
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource    `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name string    `json:"name"`
+	Unit string    `json:"unit,omitempty"`
+	Sum  *otlpSum  `json:"sum,omitempty"`
+	Gauge *otlpGauge `json:"gauge,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"` // 2 = cumulative
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes   []otlpAttr `json:"attributes,omitempty"`
+	TimeUnixNano string     `json:"timeUnixNano"`
+	AsDouble     float64    `json:"asDouble"`
+}
+
+type otlpAttr struct {
+	Key   string        `json:"key"`
+	Value otlpAttrValue `json:"value"`
+}
+
+type otlpAttrValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+func strAttr(key, value string) otlpAttr {
+	return otlpAttr{Key: key, Value: otlpAttrValue{StringValue: value}}
+}
+
+// timeUnixNano renders the current time as OTLP's dataPoint timestamp
+// format: nanoseconds since the Unix epoch, as a decimal string.
+func timeUnixNano() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// pushOTLP renders the current snapshot as an OTLP/HTTP JSON request and
+// POSTs it to opts.Endpoint, best-effort (errors are swallowed - a
+// failed push shouldn't take down the scrape loop it shares a Registry
+// with).
+func (r *Registry) pushOTLP(ctx context.Context, client *http.Client, opts OTLPOptions) {
+	now := timeUnixNano()
+
+	var attrs []otlpAttr
+	for k, v := range opts.ResourceAttrs {
+		attrs = append(attrs, strAttr(k, v))
+	}
+
+	r.mu.Lock()
+	var metrics []otlpMetric
+	for _, key := range sortedCounterKeys(r.counters) {
+		metric := "superray_uplink_bytes_total"
+		if key.direction == "downlink" {
+			metric = "superray_downlink_bytes_total"
+		}
+		value := float64(r.counters[key].base + r.counters[key].lastRaw)
+		metrics = append(metrics, otlpMetric{
+			Name: metric,
+			Unit: "By",
+			Sum: &otlpSum{
+				AggregationTemporality: 2,
+				IsMonotonic:            true,
+				DataPoints: []otlpNumberDataPoint{{
+					Attributes:   []otlpAttr{strAttr("scope", key.scope), strAttr("tag", key.tag)},
+					TimeUnixNano: now,
+					AsDouble:     value,
+				}},
+			},
+		})
+	}
+	metrics = append(metrics,
+		otlpMetric{Name: "superray_speed_bytes_per_second", Unit: "By/s", Gauge: &otlpGauge{DataPoints: []otlpNumberDataPoint{
+			{Attributes: []otlpAttr{strAttr("direction", "uplink")}, TimeUnixNano: now, AsDouble: r.speedUp},
+			{Attributes: []otlpAttr{strAttr("direction", "downlink")}, TimeUnixNano: now, AsDouble: r.speedDown},
+		}}},
+	)
+	if r.instanceID != "" {
+		metrics = append(metrics, otlpMetric{Name: "superray_instance_up", Gauge: &otlpGauge{DataPoints: []otlpNumberDataPoint{
+			{Attributes: []otlpAttr{strAttr("id", r.instanceID)}, TimeUnixNano: now, AsDouble: r.instanceUp},
+		}}})
+	}
+	r.mu.Unlock()
+
+	req := otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     otlpResource{Attributes: attrs},
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: "superray-tui"}, Metrics: metrics}},
+	}}}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// render writes the current snapshot in Prometheus text exposition
+// format to w.
+func (r *Registry) render(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP superray_uplink_bytes_total Cumulative bytes sent through an inbound or outbound.")
+	fmt.Fprintln(w, "# TYPE superray_uplink_bytes_total counter")
+	fmt.Fprintln(w, "# HELP superray_downlink_bytes_total Cumulative bytes received through an inbound or outbound.")
+	fmt.Fprintln(w, "# TYPE superray_downlink_bytes_total counter")
+	for _, key := range sortedCounterKeys(r.counters) {
+		metric := "superray_uplink_bytes_total"
+		if key.direction == "downlink" {
+			metric = "superray_downlink_bytes_total"
+		}
+		value := r.counters[key].base + r.counters[key].lastRaw
+		fmt.Fprintf(w, "%s{scope=%q,tag=%q} %d\n", metric, key.scope, key.tag, value)
+	}
+
+	fmt.Fprintln(w, "# HELP superray_speed_bytes_per_second Current instantaneous transfer rate.")
+	fmt.Fprintln(w, "# TYPE superray_speed_bytes_per_second gauge")
+	fmt.Fprintf(w, "superray_speed_bytes_per_second{direction=\"uplink\"} %g\n", r.speedUp)
+	fmt.Fprintf(w, "superray_speed_bytes_per_second{direction=\"downlink\"} %g\n", r.speedDown)
+
+	if r.instanceID != "" {
+		fmt.Fprintln(w, "# HELP superray_instance_up 1 if the Xray instance is running, 0 otherwise.")
+		fmt.Fprintln(w, "# TYPE superray_instance_up gauge")
+		fmt.Fprintf(w, "superray_instance_up{id=%q} %g\n", r.instanceID, r.instanceUp)
+	}
+
+	if len(r.hist) > 0 {
+		fmt.Fprintln(w, "# HELP superray_latency_ms Latency observed by BatchLatencyTest probe rounds since startup.")
+		fmt.Fprintln(w, "# TYPE superray_latency_ms histogram")
+		for _, key := range sortedHistKeys(r.hist) {
+			h := r.hist[key]
+			var cumulative uint64
+			for i, le := range latencyBucketsMs {
+				cumulative += h.buckets[i]
+				fmt.Fprintf(w, "superray_latency_ms_bucket{server=%q,protocol=%q,le=%q} %d\n", key.server, key.protocol, formatBound(le), cumulative)
+			}
+			cumulative += h.buckets[len(latencyBucketsMs)]
+			fmt.Fprintf(w, "superray_latency_ms_bucket{server=%q,protocol=%q,le=\"+Inf\"} %d\n", key.server, key.protocol, cumulative)
+			fmt.Fprintf(w, "superray_latency_ms_sum{server=%q,protocol=%q} %g\n", key.server, key.protocol, h.sum)
+			fmt.Fprintf(w, "superray_latency_ms_count{server=%q,protocol=%q} %d\n", key.server, key.protocol, h.count)
+		}
+	}
+}
+
+func formatBound(v float64) string {
+	s := fmt.Sprintf("%g", v)
+	return strings.TrimSuffix(s, ".0")
+}
+
+func sortedCounterKeys(m map[counterKey]*counterState) []counterKey {
+	keys := make([]counterKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].scope != keys[j].scope {
+			return keys[i].scope < keys[j].scope
+		}
+		if keys[i].tag != keys[j].tag {
+			return keys[i].tag < keys[j].tag
+		}
+		return keys[i].direction < keys[j].direction
+	})
+	return keys
+}
+
+func sortedHistKeys(m map[histKey]*histogram) []histKey {
+	keys := make([]histKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].server != keys[j].server {
+			return keys[i].server < keys[j].server
+		}
+		return keys[i].protocol < keys[j].protocol
+	})
+	return keys
+}
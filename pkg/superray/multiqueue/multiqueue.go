@@ -0,0 +1,94 @@
+// Package multiqueue spawns one reader goroutine per TUN queue opened by
+// superray.CreateSystemTUNMultiQueue, following the WireGuard-go
+// multi-queue TUN model: each queue's packets are read and fed to the
+// Xray inbound independently, so throughput scales with queue count
+// instead of serializing through a single fd and goroutine.
+package multiqueue
+
+import (
+	"fmt"
+	"sync"
+
+	"superray-tui/pkg/superray"
+)
+
+// queuePacketSize is the read buffer size for one queue - large enough
+// for any single IP packet up to a jumbo-frame MTU.
+const queuePacketSize = 65536
+
+// OnPacket is called with every packet read off a queue, along with the
+// index of the queue it arrived on; it's the caller's hook for feeding
+// the packet into the Xray inbound bound to this TUN device.
+type OnPacket func(queue int, packet []byte)
+
+// Manager runs the per-queue reader goroutines for one multi-queue TUN
+// device.
+type Manager struct {
+	tag    string
+	queues int
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start opens a multi-queue TUN device named tag and begins reading from
+// every queue, delivering each packet to onPacket. The returned Manager
+// must be stopped with Close once the device is torn down.
+func Start(tag string, addresses []string, mtu, queues int, onPacket OnPacket) (*Manager, *superray.SystemTUNInfo, error) {
+	info, err := superray.CreateSystemTUNMultiQueue(tag, addresses, mtu, queues)
+	if err != nil {
+		return nil, nil, fmt.Errorf("multiqueue: create %s: %w", tag, err)
+	}
+
+	m := &Manager{tag: tag, queues: info.Queues, stop: make(chan struct{})}
+	for i := 0; i < info.Queues; i++ {
+		m.wg.Add(1)
+		go m.readQueue(i, onPacket)
+	}
+	return m, info, nil
+}
+
+func (m *Manager) readQueue(queue int, onPacket OnPacket) {
+	defer m.wg.Done()
+	buf := make([]byte, queuePacketSize)
+	for {
+		select {
+		case <-m.stop:
+			return
+		default:
+		}
+		n, err := superray.ReadTUNQueue(m.tag, queue, buf)
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+		packet := make([]byte, n)
+		copy(packet, buf[:n])
+		onPacket(queue, packet)
+	}
+}
+
+// Write sends a packet out through the given queue - the return path for
+// traffic the Xray inbound has produced for this device.
+func (m *Manager) Write(queue int, packet []byte) error {
+	return superray.WriteTUNQueue(m.tag, queue, packet)
+}
+
+// Queues reports how many queues this Manager is reading from.
+func (m *Manager) Queues() int { return m.queues }
+
+// SetAffinity pins this device's queues onto cpus, see
+// superray.SetQueueAffinity.
+func (m *Manager) SetAffinity(cpus []int) error {
+	return superray.SetQueueAffinity(m.tag, cpus)
+}
+
+// Close stops every reader goroutine and waits for them to exit. It does
+// not close the underlying TUN device itself - callers tear that down
+// the same way they would a single-queue device (superray.CloseSystemTUN).
+func (m *Manager) Close() {
+	close(m.stop)
+	m.wg.Wait()
+}
@@ -0,0 +1,127 @@
+package superray
+
+/*
+#include <stdlib.h>
+#include "superray.h"
+*/
+import "C"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// CreateSystemTUNMultiQueue creates a system TUN device with `queues`
+// parallel queue file descriptors - IFF_MULTI_QUEUE on Linux, the
+// platform equivalent on Darwin/Windows where one exists - following the
+// WireGuard-go multi-queue TUN model: one kernel queue per reader
+// goroutine instead of every packet serializing through a single fd.
+// SystemTUNInfo.Queues reports how many queues were actually opened,
+// which may be fewer than requested on a platform without multi-queue
+// support.
+func CreateSystemTUNMultiQueue(tag string, addresses []string, mtu, queues int) (*SystemTUNInfo, error) {
+	config := map[string]interface{}{
+		"tag":       tag,
+		"mtu":       mtu,
+		"addresses": addresses,
+		"queues":    queues,
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	cConfig := C.CString(string(configJSON))
+	defer C.free(unsafe.Pointer(cConfig))
+
+	result := freeAndGetString(C.SuperRay_CreateSystemTUNMultiQueue(cConfig))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf(resp.Error)
+	}
+
+	var info SystemTUNInfo
+	if err := json.Unmarshal(resp.Data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// ReadTUNQueue reads one packet off tag's queue-th queue, base64-encoded
+// across the JSON bridge like CallbackTUNRead. It blocks until a packet
+// arrives, the queue is closed (io.EOF), or an error occurs.
+func ReadTUNQueue(tag string, queue int, buf []byte) (int, error) {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+
+	result := freeAndGetString(C.SuperRay_ReadTUNQueue(cTag, C.int(queue), C.int(len(buf))))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Success {
+		return 0, fmt.Errorf(resp.Error)
+	}
+	var data struct {
+		Data string `json:"data"`
+		EOF  bool   `json:"eof"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return 0, err
+	}
+	raw, err := base64.StdEncoding.DecodeString(data.Data)
+	if err != nil {
+		return 0, fmt.Errorf("superray: decode ReadTUNQueue payload: %w", err)
+	}
+	n := copy(buf, raw)
+	if data.EOF && n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// WriteTUNQueue writes a packet to tag's queue-th queue.
+func WriteTUNQueue(tag string, queue int, data []byte) error {
+	cTag := C.CString(tag)
+	cData := C.CString(base64.StdEncoding.EncodeToString(data))
+	defer C.free(unsafe.Pointer(cTag))
+	defer C.free(unsafe.Pointer(cData))
+
+	result := freeAndGetString(C.SuperRay_WriteTUNQueue(cTag, C.int(queue), cData))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
+
+// SetQueueAffinity pins tag's queues onto cpus round-robin (queue i onto
+// cpus[i % len(cpus)]), for users on high-throughput links who want to
+// keep each queue's interrupts and reader goroutine on the same core.
+func SetQueueAffinity(tag string, cpus []int) error {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+	cpusJSON, err := json.Marshal(cpus)
+	if err != nil {
+		return err
+	}
+	cCPUs := C.CString(string(cpusJSON))
+	defer C.free(unsafe.Pointer(cCPUs))
+
+	result := freeAndGetString(C.SuperRay_SetQueueAffinity(cTag, cCPUs))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
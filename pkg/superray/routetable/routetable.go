@@ -0,0 +1,117 @@
+// Package routetable tracks the system routes currently installed for
+// each TUN device and reconciles them against a new desired set with
+// add/remove calls for only the delta, rather than superray.SetupRoutes'
+// tear-down-and-reinstall-everything approach. This is what lets a
+// caller push a split-tunnel policy (per-CIDR routing to different
+// outbounds, per-route MTU) without racing superray.CleanupRoutes against
+// superray.SetupRoutes - the same problem nebula's overlay package solves
+// by diffing its routing table instead of rewriting it wholesale.
+package routetable
+
+import (
+	"fmt"
+	"sync"
+
+	"superray-tui/pkg/superray"
+)
+
+// Table holds the last-installed route set for every TUN tag UpdateRoutes
+// has touched.
+type Table struct {
+	mu        sync.Mutex
+	installed map[string][]superray.Route
+	watches   map[string]string // tag -> StartRouteWatch handle
+}
+
+// New builds an empty Table.
+func New() *Table {
+	return &Table{
+		installed: make(map[string][]superray.Route),
+		watches:   make(map[string]string),
+	}
+}
+
+// UpdateRoutes reconciles tag's installed routes to match routes,
+// diffing against whatever Table last installed for tag (using
+// superray.Route.Equal over CIDR/Via/Metric/MTU/Install) and issuing
+// superray.AddRoute/RemoveRoute only for entries that were added, removed,
+// or changed. The first call for a tag with no prior state installs every
+// route in routes.
+func (t *Table) UpdateRoutes(tag string, routes []superray.Route) error {
+	t.mu.Lock()
+	prev := t.installed[tag]
+	t.mu.Unlock()
+
+	toAdd, toRemove := diff(prev, routes)
+
+	var firstErr error
+	for _, r := range toRemove {
+		if !r.Install {
+			continue
+		}
+		if err := superray.RemoveRoute(tag, r); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("routetable: remove %s: %w", r.CIDR, err)
+		}
+	}
+	for _, r := range toAdd {
+		if !r.Install {
+			continue
+		}
+		if err := superray.AddRoute(tag, r); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("routetable: add %s: %w", r.CIDR, err)
+		}
+	}
+
+	t.mu.Lock()
+	t.installed[tag] = append([]superray.Route(nil), routes...)
+	t.mu.Unlock()
+
+	return firstErr
+}
+
+// Routes returns the route set Table believes is currently installed for
+// tag.
+func (t *Table) Routes(tag string) []superray.Route {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]superray.Route, len(t.installed[tag]))
+	copy(out, t.installed[tag])
+	return out
+}
+
+// Clear forgets tag's installed set without issuing any RemoveRoute
+// calls - for use after superray.CleanupRoutes has already torn
+// everything down.
+func (t *Table) Clear(tag string) {
+	t.mu.Lock()
+	delete(t.installed, tag)
+	t.mu.Unlock()
+}
+
+// diff compares prev against next keyed by CIDR, returning the routes
+// that must be added (new CIDRs, or existing CIDRs whose Via/Metric/MTU/
+// Install changed) and the routes that must be removed (CIDRs present in
+// prev but absent from next, plus the old entry of every changed CIDR).
+func diff(prev, next []superray.Route) (toAdd, toRemove []superray.Route) {
+	prevByCIDR := make(map[string]superray.Route, len(prev))
+	for _, r := range prev {
+		prevByCIDR[r.CIDR] = r
+	}
+	nextByCIDR := make(map[string]bool, len(next))
+
+	for _, r := range next {
+		nextByCIDR[r.CIDR] = true
+		if old, ok := prevByCIDR[r.CIDR]; !ok || !old.Equal(r) {
+			if ok {
+				toRemove = append(toRemove, old)
+			}
+			toAdd = append(toAdd, r)
+		}
+	}
+	for _, r := range prev {
+		if !nextByCIDR[r.CIDR] {
+			toRemove = append(toRemove, r)
+		}
+	}
+	return toAdd, toRemove
+}
@@ -0,0 +1,62 @@
+package routetable
+
+import (
+	"testing"
+
+	"superray-tui/pkg/superray"
+)
+
+func cidrs(routes []superray.Route) []string {
+	out := make([]string, len(routes))
+	for i, r := range routes {
+		out[i] = r.CIDR
+	}
+	return out
+}
+
+func TestDiffFirstInstallAddsEverything(t *testing.T) {
+	next := []superray.Route{{CIDR: "10.0.0.0/8", Install: true}, {CIDR: "0.0.0.0/0", Install: true}}
+
+	toAdd, toRemove := diff(nil, next)
+	if len(toRemove) != 0 {
+		t.Fatalf("toRemove = %v, want none on first install", toRemove)
+	}
+	if got := cidrs(toAdd); len(got) != 2 {
+		t.Fatalf("toAdd = %v, want both routes", got)
+	}
+}
+
+func TestDiffRemovesDroppedCIDR(t *testing.T) {
+	prev := []superray.Route{{CIDR: "10.0.0.0/8", Install: true}, {CIDR: "192.168.0.0/16", Install: true}}
+	next := []superray.Route{{CIDR: "10.0.0.0/8", Install: true}}
+
+	toAdd, toRemove := diff(prev, next)
+	if len(toAdd) != 0 {
+		t.Fatalf("toAdd = %v, want none", toAdd)
+	}
+	if len(toRemove) != 1 || toRemove[0].CIDR != "192.168.0.0/16" {
+		t.Fatalf("toRemove = %v, want only 192.168.0.0/16", toRemove)
+	}
+}
+
+func TestDiffReinstallsChangedRoute(t *testing.T) {
+	prev := []superray.Route{{CIDR: "10.0.0.0/8", Metric: 10, Install: true}}
+	next := []superray.Route{{CIDR: "10.0.0.0/8", Metric: 20, Install: true}}
+
+	toAdd, toRemove := diff(prev, next)
+	if len(toAdd) != 1 || toAdd[0].Metric != 20 {
+		t.Fatalf("toAdd = %v, want the updated route", toAdd)
+	}
+	if len(toRemove) != 1 || toRemove[0].Metric != 10 {
+		t.Fatalf("toRemove = %v, want the stale route", toRemove)
+	}
+}
+
+func TestDiffUnchangedRouteIsNoop(t *testing.T) {
+	route := superray.Route{CIDR: "10.0.0.0/8", Metric: 10, Install: true}
+	toAdd, toRemove := diff([]superray.Route{route}, []superray.Route{route})
+
+	if len(toAdd) != 0 || len(toRemove) != 0 {
+		t.Fatalf("toAdd=%v toRemove=%v, want no changes for an identical route set", toAdd, toRemove)
+	}
+}
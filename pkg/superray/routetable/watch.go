@@ -0,0 +1,63 @@
+package routetable
+
+import "superray-tui/pkg/superray"
+
+// WatchSystemRoutes subscribes to OS-level route/link-change
+// notifications for tag's uplink - PF_ROUTE on Darwin/BSD, netlink
+// (RTMGRP_IPV4_ROUTE|RTMGRP_LINK) on Linux, handled on the C side - and
+// forwards every event to events. When the default gateway changes (e.g.
+// Wi-Fi to Ethernet), it re-installs whatever route set Table last
+// recorded for tag via UpdateRoutes, so the TUN tunnel survives roaming
+// instead of going dark until the user reconnects manually - the same
+// pattern nebula and wireguard-go's route listeners implement.
+//
+// Call StopWatch(tag) to end the subscription.
+func (t *Table) WatchSystemRoutes(tag string, events chan<- superray.RouteEvent) error {
+	handle, err := superray.StartRouteWatch(tag)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.watches[tag] = handle
+	t.mu.Unlock()
+
+	go func() {
+		for {
+			ev, err := superray.NextRouteEvent(handle)
+			if err != nil {
+				return
+			}
+			events <- *ev
+			if ev.Type == superray.RouteEventGatewayChanged {
+				t.reinstall(tag)
+			}
+		}
+	}()
+	return nil
+}
+
+// StopWatch ends the route-change subscription started by
+// WatchSystemRoutes for tag, if any.
+func (t *Table) StopWatch(tag string) error {
+	t.mu.Lock()
+	handle, ok := t.watches[tag]
+	delete(t.watches, tag)
+	t.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return superray.StopRouteWatch(handle)
+}
+
+// reinstall re-runs SetupRoutes with tag's last-recorded route set, best
+// effort - there's no good way to surface a reinstall failure to the
+// caller from inside the watch goroutine, so it's left for the next
+// explicit UpdateRoutes/GetTUNStackStats poll to notice.
+func (t *Table) reinstall(tag string) {
+	routes := t.Routes(tag)
+	if len(routes) == 0 {
+		return
+	}
+	superray.SetupRoutes(tag, routes)
+}
@@ -0,0 +1,184 @@
+// Package routetree maps destination prefixes to outbound tags with
+// longest-prefix-match lookup, backed by a binary CIDR trie in the style
+// of cidr.Tree4 (one trie node per bit, walked MSB-first) rather than a
+// linear scan over rules. It's the Go-side counterpart of
+// superray.StartSystemTUNStackWithPolicy/UpdateTUNPolicy: the TUI builds
+// and hot-swaps a RouteTree to decide what PolicyRule set to push down,
+// and can also use it directly to show which outbound a given
+// destination would resolve to.
+package routetree
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"superray-tui/pkg/superray"
+)
+
+// node is one bit-level trie node. A nil children entry means that branch
+// hasn't been populated; a node with rule != nil is itself the endpoint
+// of some inserted prefix (it may also have children, for longer
+// prefixes nested under it).
+type node struct {
+	children [2]*node
+	rule     *superray.PolicyRule
+}
+
+// RouteTree is a CIDR trie mapping destination prefixes to outbound
+// tags, safe for concurrent lookups and updates.
+type RouteTree struct {
+	mu   sync.RWMutex
+	root *node
+}
+
+// New builds an empty RouteTree.
+func New() *RouteTree {
+	return &RouteTree{root: &node{}}
+}
+
+// Insert adds or replaces the rule for cidr.
+func (t *RouteTree) Insert(rule superray.PolicyRule) error {
+	ip, bits, err := parseCIDR(rule.CIDR)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cur := t.root
+	for i := 0; i < bits; i++ {
+		bit := bitAt(ip, i)
+		if cur.children[bit] == nil {
+			cur.children[bit] = &node{}
+		}
+		cur = cur.children[bit]
+	}
+	r := rule
+	cur.rule = &r
+	return nil
+}
+
+// Remove deletes the rule previously inserted for cidr, if any.
+func (t *RouteTree) Remove(cidr string) {
+	ip, bits, err := parseCIDR(cidr)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cur := t.root
+	for i := 0; i < bits; i++ {
+		bit := bitAt(ip, i)
+		if cur.children[bit] == nil {
+			return
+		}
+		cur = cur.children[bit]
+	}
+	cur.rule = nil
+}
+
+// Lookup returns the rule whose prefix most specifically matches ip,
+// falling back to ok == false if no rule covers it at all.
+func (t *RouteTree) Lookup(ip net.IP) (superray.PolicyRule, bool) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return superray.PolicyRule{}, false
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	cur := t.root
+	var best *superray.PolicyRule
+	if cur.rule != nil {
+		best = cur.rule
+	}
+	for i := 0; i < 32; i++ {
+		bit := bitAt(ip4, i)
+		if cur.children[bit] == nil {
+			break
+		}
+		cur = cur.children[bit]
+		if cur.rule != nil {
+			best = cur.rule
+		}
+	}
+	if best == nil {
+		return superray.PolicyRule{}, false
+	}
+	return *best, true
+}
+
+// Rules returns every rule currently in the tree, sorted by CIDR for
+// stable output - the slice ReplaceAll (or an initial
+// StartSystemTUNStackWithPolicy call) would push down as a whole.
+func (t *RouteTree) Rules() []superray.PolicyRule {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var out []superray.PolicyRule
+	collect(t.root, &out)
+	sort.Slice(out, func(i, j int) bool { return out[i].CIDR < out[j].CIDR })
+	return out
+}
+
+func collect(n *node, out *[]superray.PolicyRule) {
+	if n == nil {
+		return
+	}
+	if n.rule != nil {
+		*out = append(*out, *n.rule)
+	}
+	collect(n.children[0], out)
+	collect(n.children[1], out)
+}
+
+// ReplaceAll rebuilds the tree from rules in one step and pushes the new
+// rule set down to tag's running TUN stack via superray.UpdateTUNPolicy,
+// hot-swapping without dropping the device.
+func (t *RouteTree) ReplaceAll(tag string, rules []superray.PolicyRule) error {
+	fresh := &node{}
+	for _, rule := range rules {
+		r := rule
+		ip, bits, err := parseCIDR(rule.CIDR)
+		if err != nil {
+			return err
+		}
+		cur := fresh
+		for i := 0; i < bits; i++ {
+			bit := bitAt(ip, i)
+			if cur.children[bit] == nil {
+				cur.children[bit] = &node{}
+			}
+			cur = cur.children[bit]
+		}
+		cur.rule = &r
+	}
+
+	t.mu.Lock()
+	t.root = fresh
+	t.mu.Unlock()
+
+	return superray.UpdateTUNPolicy(tag, rules)
+}
+
+func parseCIDR(cidr string) (net.IP, int, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, 0, fmt.Errorf("routetree: invalid CIDR %q: %w", cidr, err)
+	}
+	ip4 := network.IP.To4()
+	if ip4 == nil {
+		return nil, 0, fmt.Errorf("routetree: only IPv4 CIDRs are supported, got %q", cidr)
+	}
+	ones, _ := network.Mask.Size()
+	return ip4, ones, nil
+}
+
+func bitAt(ip net.IP, i int) int {
+	byteIdx := i / 8
+	bitIdx := 7 - uint(i%8)
+	return int((ip[byteIdx] >> bitIdx) & 1)
+}
@@ -0,0 +1,81 @@
+package routetree
+
+import (
+	"net"
+	"testing"
+
+	"superray-tui/pkg/superray"
+)
+
+func TestLookupLongestPrefixMatch(t *testing.T) {
+	tr := New()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+	must(tr.Insert(superray.PolicyRule{CIDR: "10.0.0.0/8", OutboundTag: "wide"}))
+	must(tr.Insert(superray.PolicyRule{CIDR: "10.1.0.0/16", OutboundTag: "narrow"}))
+
+	tests := []struct {
+		ip      string
+		wantTag string
+		wantOK  bool
+	}{
+		{"10.1.2.3", "narrow", true},
+		{"10.2.2.3", "wide", true},
+		{"8.8.8.8", "", false},
+	}
+	for _, tt := range tests {
+		rule, ok := tr.Lookup(net.ParseIP(tt.ip))
+		if ok != tt.wantOK {
+			t.Errorf("Lookup(%s) ok = %v, want %v", tt.ip, ok, tt.wantOK)
+			continue
+		}
+		if ok && rule.OutboundTag != tt.wantTag {
+			t.Errorf("Lookup(%s) tag = %q, want %q", tt.ip, rule.OutboundTag, tt.wantTag)
+		}
+	}
+}
+
+func TestRemoveDropsRule(t *testing.T) {
+	tr := New()
+	if err := tr.Insert(superray.PolicyRule{CIDR: "192.168.0.0/16", OutboundTag: "lan"}); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	tr.Remove("192.168.0.0/16")
+
+	if _, ok := tr.Lookup(net.ParseIP("192.168.1.1")); ok {
+		t.Fatal("Lookup found a rule after Remove")
+	}
+}
+
+func TestRulesSortedByCIDR(t *testing.T) {
+	tr := New()
+	for _, cidr := range []string{"10.0.0.0/8", "1.2.3.0/24", "172.16.0.0/12"} {
+		if err := tr.Insert(superray.PolicyRule{CIDR: cidr, OutboundTag: "x"}); err != nil {
+			t.Fatalf("Insert(%s): %v", cidr, err)
+		}
+	}
+
+	rules := tr.Rules()
+	if len(rules) != 3 {
+		t.Fatalf("len(Rules()) = %d, want 3", len(rules))
+	}
+	for i := 1; i < len(rules); i++ {
+		if rules[i-1].CIDR >= rules[i].CIDR {
+			t.Fatalf("Rules() not sorted: %q before %q", rules[i-1].CIDR, rules[i].CIDR)
+		}
+	}
+}
+
+func TestInsertRejectsIPv6AndMalformedCIDR(t *testing.T) {
+	tr := New()
+	if err := tr.Insert(superray.PolicyRule{CIDR: "not-a-cidr", OutboundTag: "x"}); err == nil {
+		t.Error("Insert: want error for malformed CIDR")
+	}
+	if err := tr.Insert(superray.PolicyRule{CIDR: "fc00::/7", OutboundTag: "x"}); err == nil {
+		t.Error("Insert: want error for IPv6 CIDR")
+	}
+}
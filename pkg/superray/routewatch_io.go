@@ -0,0 +1,101 @@
+package superray
+
+/*
+#include <stdlib.h>
+#include "superray.h"
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+)
+
+// RouteEventType is the kind of OS-level network change
+// StartRouteWatch/NextRouteEvent reports.
+type RouteEventType string
+
+const (
+	// RouteEventGatewayChanged fires when the default route's gateway or
+	// outbound interface changes - e.g. roaming from Wi-Fi to Ethernet.
+	RouteEventGatewayChanged RouteEventType = "gateway_changed"
+	// RouteEventLinkDown fires when the underlying uplink interface goes
+	// down.
+	RouteEventLinkDown RouteEventType = "link_down"
+	// RouteEventMTUChanged fires when the underlying uplink's MTU
+	// changes.
+	RouteEventMTUChanged RouteEventType = "mtu_changed"
+)
+
+// RouteEvent is one change reported by the OS's route/link notification
+// socket - PF_ROUTE on Darwin/BSD, netlink's RTMGRP_IPV4_ROUTE|
+// RTMGRP_LINK groups on Linux.
+type RouteEvent struct {
+	Type      RouteEventType `json:"type"`
+	Interface string         `json:"interface,omitempty"`
+	Gateway   string         `json:"gateway,omitempty"`
+	MTU       int            `json:"mtu,omitempty"`
+}
+
+// StartRouteWatch subscribes to route/link-change notifications for
+// tag's underlying uplink, returning an opaque handle NextRouteEvent
+// polls and StopRouteWatch releases.
+func StartRouteWatch(tag string) (string, error) {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+
+	result := freeAndGetString(C.SuperRay_StartRouteWatch(cTag))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return "", err
+	}
+	if !resp.Success {
+		return "", fmt.Errorf(resp.Error)
+	}
+	var data struct {
+		Handle string `json:"handle"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return "", err
+	}
+	return data.Handle, nil
+}
+
+// NextRouteEvent blocks until the next RouteEvent arrives on handle, or
+// returns an error once StopRouteWatch(handle) has been called -
+// matching CallbackTUNAccept's "poll until Close" contract.
+func NextRouteEvent(handle string) (*RouteEvent, error) {
+	cHandle := C.CString(handle)
+	defer C.free(unsafe.Pointer(cHandle))
+
+	result := freeAndGetString(C.SuperRay_NextRouteEvent(cHandle))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf(resp.Error)
+	}
+	var ev RouteEvent
+	if err := json.Unmarshal(resp.Data, &ev); err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// StopRouteWatch releases a handle returned by StartRouteWatch, causing
+// any blocked NextRouteEvent call to return an error.
+func StopRouteWatch(handle string) error {
+	cHandle := C.CString(handle)
+	defer C.free(unsafe.Pointer(cHandle))
+
+	result := freeAndGetString(C.SuperRay_StopRouteWatch(cHandle))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+)
+
+// Strategy is one of the balancer selection strategies Xray's
+// app/router/config.proto defines.
+type Strategy string
+
+const (
+	StrategyRoundRobin Strategy = "roundRobin"
+	StrategyLeastPing  Strategy = "leastPing"
+	StrategyRandom     Strategy = "random"
+)
+
+// HealthCheck configures the active probing StrategyLeastPing needs to
+// rank outbounds by latency; the other strategies ignore it. Zero values
+// fall back to Xray's own built-in defaults, so a caller only needs to
+// set the fields it wants to override.
+type HealthCheck struct {
+	Destination   string        // probe target URL; Xray defaults to its own connectivity-check endpoint
+	Interval      time.Duration // time between probe rounds
+	SamplingCount int           // recent probes averaged into the reported latency
+	Timeout       time.Duration // per-probe timeout before it counts as a failure
+}
+
+// Balancer is one Xray routing.balancers entry: a named pool of outbound
+// tags plus the strategy used to pick among them when a rule targets it
+// by BalancerTag instead of a fixed OutboundTag.
+type Balancer struct {
+	Tag      string
+	Selector []string // outbound tags (or tag prefixes) eligible for selection
+	Strategy Strategy
+	Health   HealthCheck // only emitted for StrategyLeastPing
+}
+
+// Validate checks the fields BuildRoutingConfig can't infer on its own.
+func (b Balancer) Validate() error {
+	if b.Tag == "" {
+		return fmt.Errorf("routing: balancer has no tag")
+	}
+	if len(b.Selector) == 0 {
+		return fmt.Errorf("routing: balancer %q has no selector", b.Tag)
+	}
+	switch b.Strategy {
+	case StrategyRoundRobin, StrategyLeastPing, StrategyRandom:
+	default:
+		return fmt.Errorf("routing: balancer %q has unknown strategy %q", b.Tag, b.Strategy)
+	}
+	return nil
+}
+
+func (b Balancer) toXray() map[string]interface{} {
+	out := map[string]interface{}{
+		"tag":      b.Tag,
+		"selector": b.Selector,
+		"strategy": map[string]interface{}{"type": string(b.Strategy)},
+	}
+	if b.Strategy != StrategyLeastPing {
+		return out
+	}
+
+	settings := map[string]interface{}{}
+	if b.Health.Destination != "" {
+		settings["destination"] = b.Health.Destination
+	}
+	if b.Health.Interval > 0 {
+		settings["interval"] = b.Health.Interval.String()
+	}
+	if b.Health.SamplingCount > 0 {
+		settings["samplingCount"] = b.Health.SamplingCount
+	}
+	if b.Health.Timeout > 0 {
+		settings["timeout"] = b.Health.Timeout.String()
+	}
+	if len(settings) > 0 {
+		out["strategy"].(map[string]interface{})["settings"] = settings
+	}
+	return out
+}
@@ -0,0 +1,199 @@
+// Package routing builds Xray's full "routing" config block - the
+// v2ray.com/core/app/router schema - from a typed RoutingRule slice.
+// superray.CreateRoutingRuleDomain/CreateRoutingRuleIP only cover the two
+// simplest matcher shapes; this package adds every field-rule matcher
+// Xray supports (source/dest IP and port, network, inbound tag, sniffed
+// protocol, user email, attrs regex) plus balancer objects, so callers
+// building a config from scratch don't have to hand-assemble the JSON
+// main.go's buildConfig does inline.
+package routing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RoutingRule is one Xray "field" routing rule. Every matcher field is
+// optional and ANDed together; a rule with no matcher set at all is
+// rejected by Validate since it would match everything regardless of
+// intent.
+type RoutingRule struct {
+	// Domain entries are plain domains, "domain:"-prefixed subdomain
+	// matches, "regexp:"-prefixed patterns, or "geosite:"-prefixed
+	// category refs, exactly as Xray's RuleObject.domain accepts.
+	Domain []string
+	// IP entries are CIDRs or "geoip:"-prefixed country/category refs.
+	IP []string
+
+	Port       string // dest port(s), e.g. "443" or "1000-2000,8443"
+	SourcePort string // source port(s), same syntax as Port
+
+	SourceIP []string // CIDRs traffic must originate from
+
+	// Network restricts by transport: "tcp", "udp", or "tcp,udp".
+	Network string
+
+	InboundTag []string
+
+	// Protocol lists sniffed application protocols this rule matches:
+	// "http", "tls", "quic", "bittorrent".
+	Protocol []string
+
+	// User matches inbound auth'd user emails (only meaningful behind
+	// inbounds that authenticate, e.g. VMess/VLESS/Trojan).
+	User []string
+
+	// Attrs is a regex matched against sniffed HTTP request attributes
+	// (":path", ":method", header names), as Xray's attrs field is.
+	Attrs string
+
+	// OutboundTag and BalancerTag are mutually exclusive; exactly one
+	// selects where matching traffic goes.
+	OutboundTag string
+	BalancerTag string
+
+	// Priority orders rules lowest-first in the emitted config; rules
+	// sharing a Priority keep their input order (Xray evaluates rules
+	// top to bottom, first match wins).
+	Priority int
+}
+
+// hasMatcher reports whether r constrains traffic by anything at all.
+func (r RoutingRule) hasMatcher() bool {
+	return len(r.Domain) > 0 || len(r.IP) > 0 || r.Port != "" || r.SourcePort != "" ||
+		len(r.SourceIP) > 0 || r.Network != "" || len(r.InboundTag) > 0 ||
+		len(r.Protocol) > 0 || len(r.User) > 0 || r.Attrs != ""
+}
+
+// Validate checks r for the mistakes Xray would otherwise reject at
+// config-load time: no matcher, no/both targets, and (when assetDir is
+// set) a geosite:/geoip: ref with no asset file to resolve it against.
+// assetDir may be empty to skip that last check, e.g. when the caller
+// doesn't manage geo assets itself.
+func (r RoutingRule) Validate(assetDir string) error {
+	if !r.hasMatcher() {
+		return fmt.Errorf("routing: rule has no matcher")
+	}
+	if r.OutboundTag == "" && r.BalancerTag == "" {
+		return fmt.Errorf("routing: rule has neither outboundTag nor balancerTag")
+	}
+	if r.OutboundTag != "" && r.BalancerTag != "" {
+		return fmt.Errorf("routing: rule has both outboundTag and balancerTag")
+	}
+	if assetDir != "" {
+		if err := checkGeoRefs(assetDir, "geosite", r.Domain); err != nil {
+			return err
+		}
+		if err := checkGeoRefs(assetDir, "geoip", r.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkGeoRefs fails if any entry in values carries the kind+":" prefix
+// (e.g. "geosite:google") but kind+".dat" isn't present in assetDir. It
+// doesn't parse the .dat file's categories - same trade-off as
+// ruleset.Manager.EnsureGeoAssets, which only guarantees the file exists.
+func checkGeoRefs(assetDir, kind string, values []string) error {
+	prefix := kind + ":"
+	var needed bool
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(assetDir, kind+".dat")); err != nil {
+		return fmt.Errorf("routing: rule references %s but %s.dat is missing from %s: %w", prefix, kind, assetDir, err)
+	}
+	return nil
+}
+
+// toXray renders r as the JSON object Xray's routing.rules expects.
+func (r RoutingRule) toXray() map[string]interface{} {
+	rule := map[string]interface{}{"type": "field"}
+	if len(r.Domain) > 0 {
+		rule["domain"] = r.Domain
+	}
+	if len(r.IP) > 0 {
+		rule["ip"] = r.IP
+	}
+	if r.Port != "" {
+		rule["port"] = r.Port
+	}
+	if r.SourcePort != "" {
+		rule["sourcePort"] = r.SourcePort
+	}
+	if len(r.SourceIP) > 0 {
+		rule["source"] = r.SourceIP
+	}
+	if r.Network != "" {
+		rule["network"] = r.Network
+	}
+	if len(r.InboundTag) > 0 {
+		rule["inboundTag"] = r.InboundTag
+	}
+	if len(r.Protocol) > 0 {
+		rule["protocol"] = r.Protocol
+	}
+	if len(r.User) > 0 {
+		rule["user"] = r.User
+	}
+	if r.Attrs != "" {
+		rule["attrs"] = r.Attrs
+	}
+	if r.OutboundTag != "" {
+		rule["outboundTag"] = r.OutboundTag
+	} else {
+		rule["balancerTag"] = r.BalancerTag
+	}
+	return rule
+}
+
+// BuildRoutingConfig validates rules, orders them by Priority (stable
+// within a priority, so equal-priority rules keep callers' relative
+// order), and renders the Xray "routing" config block. assetDir enables
+// the geosite:/geoip: asset check in Validate; pass "" to skip it.
+func BuildRoutingConfig(rules []RoutingRule, domainStrategy string, assetDir string, balancers ...Balancer) (map[string]interface{}, error) {
+	ordered := append([]RoutingRule{}, rules...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Priority < ordered[j].Priority })
+
+	xrayRules := make([]map[string]interface{}, 0, len(ordered))
+	for i, r := range ordered {
+		if err := r.Validate(assetDir); err != nil {
+			return nil, fmt.Errorf("routing: rule %d: %w", i, err)
+		}
+		xrayRules = append(xrayRules, r.toXray())
+	}
+
+	balancerTags := make(map[string]bool, len(balancers))
+	xrayBalancers := make([]map[string]interface{}, 0, len(balancers))
+	for i, b := range balancers {
+		if err := b.Validate(); err != nil {
+			return nil, fmt.Errorf("routing: balancer %d: %w", i, err)
+		}
+		balancerTags[b.Tag] = true
+		xrayBalancers = append(xrayBalancers, b.toXray())
+	}
+	for i, r := range ordered {
+		if r.BalancerTag != "" && !balancerTags[r.BalancerTag] {
+			return nil, fmt.Errorf("routing: rule %d: balancerTag %q has no matching Balancer", i, r.BalancerTag)
+		}
+	}
+
+	config := map[string]interface{}{
+		"domainStrategy": domainStrategy,
+		"rules":          xrayRules,
+	}
+	if len(xrayBalancers) > 0 {
+		config["balancers"] = xrayBalancers
+	}
+	return config, nil
+}
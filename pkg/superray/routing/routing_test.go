@@ -0,0 +1,111 @@
+package routing
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRejectsRuleWithNoMatcher(t *testing.T) {
+	r := RoutingRule{OutboundTag: "proxy"}
+	if err := r.Validate(""); err == nil {
+		t.Fatal("Validate: want error for a rule with no matcher")
+	}
+}
+
+func TestValidateRejectsMissingAndDualTargets(t *testing.T) {
+	noTarget := RoutingRule{Domain: []string{"example.com"}}
+	if err := noTarget.Validate(""); err == nil {
+		t.Fatal("Validate: want error when neither outboundTag nor balancerTag is set")
+	}
+
+	both := RoutingRule{Domain: []string{"example.com"}, OutboundTag: "proxy", BalancerTag: "pool"}
+	if err := both.Validate(""); err == nil {
+		t.Fatal("Validate: want error when both outboundTag and balancerTag are set")
+	}
+}
+
+func TestValidateChecksGeoAssetsWhenAssetDirSet(t *testing.T) {
+	dir := t.TempDir()
+
+	r := RoutingRule{Domain: []string{"geosite:google"}, OutboundTag: "proxy"}
+	if err := r.Validate(dir); err == nil {
+		t.Fatal("Validate: want error, geosite.dat is missing from assetDir")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "geosite.dat"), []byte{}, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := r.Validate(dir); err != nil {
+		t.Fatalf("Validate: %v, want no error once geosite.dat exists", err)
+	}
+
+	// Without an assetDir the check is skipped entirely.
+	if err := r.Validate(""); err != nil {
+		t.Fatalf("Validate(\"\"): %v, want geo asset check skipped", err)
+	}
+}
+
+func TestBuildRoutingConfigOrdersByPriority(t *testing.T) {
+	rules := []RoutingRule{
+		{Domain: []string{"b.com"}, OutboundTag: "proxy", Priority: 2},
+		{Domain: []string{"a.com"}, OutboundTag: "direct", Priority: 1},
+	}
+
+	cfg, err := BuildRoutingConfig(rules, "AsIs", "")
+	if err != nil {
+		t.Fatalf("BuildRoutingConfig: %v", err)
+	}
+	out := cfg["rules"].([]map[string]interface{})
+	if len(out) != 2 {
+		t.Fatalf("len(rules) = %d, want 2", len(out))
+	}
+	if out[0]["outboundTag"] != "direct" {
+		t.Fatalf("rules[0] = %v, want the Priority:1 rule first", out[0])
+	}
+}
+
+func TestBuildRoutingConfigRejectsDanglingBalancerTag(t *testing.T) {
+	rules := []RoutingRule{{Domain: []string{"a.com"}, BalancerTag: "pool"}}
+
+	if _, err := BuildRoutingConfig(rules, "AsIs", ""); err == nil {
+		t.Fatal("BuildRoutingConfig: want error, balancerTag has no matching Balancer")
+	}
+	if _, err := BuildRoutingConfig(rules, "AsIs", "", Balancer{}); err == nil {
+		t.Fatal("BuildRoutingConfig: want the invalid Balancer itself to fail validation")
+	}
+}
+
+func TestBuildRoutingConfigAcceptsMatchingBalancer(t *testing.T) {
+	rules := []RoutingRule{{Domain: []string{"a.com"}, BalancerTag: "pool"}}
+	balancer := Balancer{Tag: "pool", Selector: []string{"proxy-"}, Strategy: StrategyRoundRobin}
+
+	cfg, err := BuildRoutingConfig(rules, "AsIs", "", balancer)
+	if err != nil {
+		t.Fatalf("BuildRoutingConfig: %v", err)
+	}
+	if _, ok := cfg["balancers"]; !ok {
+		t.Fatal("BuildRoutingConfig: want a balancers entry in the output")
+	}
+}
+
+func TestBalancerValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       Balancer
+		wantErr string
+	}{
+		{"missing tag", Balancer{Selector: []string{"x"}, Strategy: StrategyRandom}, "no tag"},
+		{"missing selector", Balancer{Tag: "pool", Strategy: StrategyRandom}, "no selector"},
+		{"unknown strategy", Balancer{Tag: "pool", Selector: []string{"x"}, Strategy: "bogus"}, "unknown strategy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.b.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
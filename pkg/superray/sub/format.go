@@ -0,0 +1,193 @@
+package sub
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"superray-tui/pkg/superray"
+)
+
+// fetch GETs rawURL and reads off its Subscription-Userinfo header (the
+// upload/download/total/expire accounting Shadowsocks/Clash subscription
+// hosts report), returning the response body alongside it.
+func fetch(ctx context.Context, client *http.Client, rawURL string) (string, SubscriptionInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", SubscriptionInfo{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", SubscriptionInfo{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", SubscriptionInfo{}, fmt.Errorf("sub: fetch %s: unexpected status %s", rawURL, resp.Status)
+	}
+
+	var buf strings.Builder
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return "", SubscriptionInfo{}, err
+	}
+
+	return buf.String(), parseUserinfo(resp.Header.Get("Subscription-Userinfo")), nil
+}
+
+// parseUserinfo parses a Subscription-Userinfo header of the form
+// "upload=1234; download=5678; total=9999999; expire=1723996800" - any
+// field may be absent.
+func parseUserinfo(header string) SubscriptionInfo {
+	var info SubscriptionInfo
+	for _, part := range strings.Split(header, ";") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		val, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "upload":
+			info.Upload = val
+		case "download":
+			info.Download = val
+		case "total":
+			info.Total = val
+		case "expire":
+			info.Expire = time.Unix(val, 0)
+		}
+	}
+	return info
+}
+
+// parse auto-detects body's format - SIP008 JSON, Clash-style YAML, or
+// the base64-wrapped share-link list superray.ParseShareLinks already
+// handles - and returns its servers.
+func parse(body string) ([]*superray.Server, error) {
+	trimmed := strings.TrimSpace(body)
+
+	if strings.HasPrefix(trimmed, "{") {
+		if servers, err := parseSIP008(trimmed); err == nil {
+			return servers, nil
+		}
+	}
+	if strings.HasPrefix(trimmed, "proxies:") || strings.Contains(trimmed, "\nproxies:") {
+		if servers, err := parseClashYAML(trimmed); err == nil {
+			return servers, nil
+		}
+	}
+
+	decoded := trimmed
+	if raw, err := base64.StdEncoding.DecodeString(trimmed); err == nil {
+		decoded = string(raw)
+	} else if raw, err := base64.RawStdEncoding.DecodeString(trimmed); err == nil {
+		decoded = string(raw)
+	}
+	return superray.ParseShareLinks(decoded)
+}
+
+// sip008Doc is the SIP008 (Shadowsocks JSON subscription) document shape:
+// https://shadowsocks.org/doc/sip008.html
+type sip008Doc struct {
+	Servers []sip008Server `json:"servers"`
+}
+
+type sip008Server struct {
+	Remarks    string `json:"remarks"`
+	Server     string `json:"server"`
+	ServerPort int    `json:"server_port"`
+	Password   string `json:"password"`
+	Method     string `json:"method"`
+	Plugin     string `json:"plugin,omitempty"`
+	PluginOpts string `json:"plugin_opts,omitempty"`
+}
+
+func parseSIP008(body string) ([]*superray.Server, error) {
+	var doc sip008Doc
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Servers) == 0 {
+		return nil, fmt.Errorf("sub: not a SIP008 document")
+	}
+	out := make([]*superray.Server, 0, len(doc.Servers))
+	for _, s := range doc.Servers {
+		out = append(out, &superray.Server{
+			Name:     s.Remarks,
+			Protocol: "shadowsocks",
+			Address:  s.Server,
+			Port:     s.ServerPort,
+			Password: s.Password,
+			Method:   s.Method,
+			Latency:  -1,
+		})
+	}
+	return out, nil
+}
+
+// clashDoc is the subset of a Clash configuration's "proxies" list this
+// parser understands - vmess/vless/trojan/shadowsocks, the same
+// protocols superray.ParseShareLink covers for the base64 format.
+type clashDoc struct {
+	Proxies []clashProxy `yaml:"proxies"`
+}
+
+type clashProxy struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Server   string `yaml:"server"`
+	Port     int    `yaml:"port"`
+	UUID     string `yaml:"uuid"`
+	Password string `yaml:"password"`
+	Cipher   string `yaml:"cipher"`
+	Network  string `yaml:"network"`
+	TLS      bool   `yaml:"tls"`
+	SNI      string `yaml:"servername"`
+	ALPN     string `yaml:"alpn"`
+	Flow     string `yaml:"flow"`
+}
+
+func parseClashYAML(body string) ([]*superray.Server, error) {
+	var doc clashDoc
+	if err := yaml.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Proxies) == 0 {
+		return nil, fmt.Errorf("sub: not a Clash proxy list")
+	}
+	out := make([]*superray.Server, 0, len(doc.Proxies))
+	for _, p := range doc.Proxies {
+		protocol := p.Type
+		if protocol == "ss" {
+			protocol = "shadowsocks"
+		}
+		tls := ""
+		if p.TLS {
+			tls = "tls"
+		}
+		out = append(out, &superray.Server{
+			Name:     p.Name,
+			Protocol: protocol,
+			Address:  p.Server,
+			Port:     p.Port,
+			UUID:     p.UUID,
+			Password: p.Password,
+			Method:   p.Cipher,
+			Network:  p.Network,
+			TLS:      tls,
+			SNI:      p.SNI,
+			ALPN:     p.ALPN,
+			Flow:     p.Flow,
+			Latency:  -1,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package sub
+
+import "superray-tui/pkg/superray"
+
+// serverKey is the composite identity a server keeps across subscription
+// refreshes: protocol/address/port plus whichever credential field it
+// uses, so the same node reappearing under a renamed "remarks" field
+// still merges onto its previous entry.
+type serverKey struct {
+	protocol string
+	address  string
+	port     int
+	uuid     string
+	password string
+}
+
+func keyOf(s *superray.Server) serverKey {
+	return serverKey{
+		protocol: s.Protocol,
+		address:  s.Address,
+		port:     s.Port,
+		uuid:     s.UUID,
+		password: s.Password,
+	}
+}
+
+// mergeServers diffs fresh against prev, preserving each surviving
+// server's user-editable Name and accumulated Latency, and returns the
+// merged list alongside the Added/Removed/Changed events the diff
+// produced.
+func mergeServers(prev, fresh []*superray.Server, subscription string) ([]*superray.Server, []Event) {
+	prevByKey := make(map[serverKey]*superray.Server, len(prev))
+	for _, s := range prev {
+		prevByKey[keyOf(s)] = s
+	}
+
+	merged := make([]*superray.Server, 0, len(fresh))
+	seen := make(map[serverKey]bool, len(fresh))
+	var events []Event
+
+	for _, s := range fresh {
+		k := keyOf(s)
+		seen[k] = true
+		old, existed := prevByKey[k]
+		if !existed {
+			merged = append(merged, s)
+			events = append(events, Event{Type: EventAdded, Subscription: subscription, Server: s})
+			continue
+		}
+
+		// Preserve the user's own edits and the latency history
+		// BatchLatencyTest has accumulated, but take every other field
+		// from the fresh fetch - it may have rotated ports/keys.
+		next := *s
+		next.Name = old.Name
+		next.Latency = old.Latency
+		merged = append(merged, &next)
+		if changed(old, &next) {
+			events = append(events, Event{Type: EventChanged, Subscription: subscription, Server: &next})
+		}
+	}
+
+	for k, s := range prevByKey {
+		if !seen[k] {
+			events = append(events, Event{Type: EventRemoved, Subscription: subscription, Server: s})
+		}
+	}
+
+	return merged, events
+}
+
+// changed reports whether any field a refresh could plausibly update
+// (beyond the Name/Latency this package already preserves) differs
+// between old and next.
+func changed(old, next *superray.Server) bool {
+	return old.Network != next.Network ||
+		old.TLS != next.TLS ||
+		old.SNI != next.SNI ||
+		old.Path != next.Path ||
+		old.Host != next.Host ||
+		old.Flow != next.Flow ||
+		old.Security != next.Security ||
+		old.PublicKey != next.PublicKey ||
+		old.ShortID != next.ShortID ||
+		old.Fingerprint != next.Fingerprint ||
+		old.AlterId != next.AlterId ||
+		old.ALPN != next.ALPN ||
+		old.HeaderType != next.HeaderType ||
+		old.Link != next.Link
+}
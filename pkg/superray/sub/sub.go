@@ -0,0 +1,222 @@
+// Package sub schedules subscription refreshes - superray.AddSubscription
+// plus superray.UpdateSubscription only run on demand - and adds the two
+// proxy-list formats they don't cover (SIP008 Shadowsocks JSON and
+// Clash-style YAML) alongside the base64 share-link format superray
+// already parses. Refreshes are diff-merged against the previous server
+// list so a user's renamed entries and accumulated latency history
+// survive a subscription update, and every Added/Removed/Changed server
+// is reported on a channel instead of requiring callers to poll.
+package sub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"superray-tui/pkg/superray"
+)
+
+// SubscriptionInfo is the traffic/expiry accounting a subscription host
+// reports via the Subscription-Userinfo response header (the same header
+// Shadowsocks/Clash subscription servers use), if it sends one.
+type SubscriptionInfo struct {
+	Upload   int64
+	Download int64
+	Total    int64
+	Expire   time.Time // zero if the header omitted or didn't carry "expire"
+}
+
+// Subscription is one named, independently-refreshable server list.
+type Subscription struct {
+	Name string
+	URL  string
+
+	// Interval is this subscription's own refresh cadence - "cron-style"
+	// in the same sense pkg/ruleset.Provider.Interval is: a fixed period
+	// per subscription, not a crontab expression - 0 disables its
+	// automatic refresh (Manager.Refresh can still be called manually).
+	Interval time.Duration
+
+	mu        sync.RWMutex
+	servers   []*superray.Server
+	info      SubscriptionInfo
+	updatedAt time.Time
+}
+
+// Servers returns a snapshot of the subscription's current server list.
+func (s *Subscription) Servers() []*superray.Server {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*superray.Server, len(s.servers))
+	copy(out, s.servers)
+	return out
+}
+
+// Info returns the most recently reported SubscriptionInfo.
+func (s *Subscription) Info() SubscriptionInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.info
+}
+
+// UpdatedAt reports when the subscription's server list was last
+// (re)loaded.
+func (s *Subscription) UpdatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updatedAt
+}
+
+// EventType is the kind of change Manager reports on its Events channel.
+type EventType string
+
+const (
+	EventAdded   EventType = "added"
+	EventRemoved EventType = "removed"
+	EventChanged EventType = "changed"
+)
+
+// Event is one server-level change a refresh produced.
+type Event struct {
+	Type         EventType
+	Subscription string
+	Server       *superray.Server
+}
+
+// Manager owns the set of configured subscriptions and reports every
+// server-level change a refresh produces on Events().
+type Manager struct {
+	mu     sync.RWMutex
+	subs   map[string]*Subscription
+	client *http.Client
+	events chan Event
+}
+
+// NewManager builds an empty Manager. Events must be drained by the
+// caller - it's buffered, but a refresh blocks once it fills up.
+func NewManager() *Manager {
+	return &Manager{
+		subs:   make(map[string]*Subscription),
+		client: &http.Client{Timeout: 20 * time.Second},
+		events: make(chan Event, 256),
+	}
+}
+
+// Events returns the channel Added/Removed/Changed events are sent on.
+func (m *Manager) Events() <-chan Event { return m.events }
+
+// Add registers sub, replacing any existing subscription of the same
+// name, and performs its initial fetch.
+func (m *Manager) Add(ctx context.Context, sub *Subscription) error {
+	m.mu.Lock()
+	m.subs[sub.Name] = sub
+	m.mu.Unlock()
+	return m.Refresh(ctx, sub.Name)
+}
+
+// Remove unregisters name, reporting EventRemoved for every server it
+// was carrying.
+func (m *Manager) Remove(name string) {
+	m.mu.Lock()
+	sub, ok := m.subs[name]
+	delete(m.subs, name)
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	for _, s := range sub.Servers() {
+		m.events <- Event{Type: EventRemoved, Subscription: name, Server: s}
+	}
+}
+
+// Get returns the named subscription, if registered.
+func (m *Manager) Get(name string) (*Subscription, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sub, ok := m.subs[name]
+	return sub, ok
+}
+
+// List returns every registered subscription, in no particular order.
+func (m *Manager) List() []*Subscription {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*Subscription, 0, len(m.subs))
+	for _, sub := range m.subs {
+		out = append(out, sub)
+	}
+	return out
+}
+
+// Refresh fetches and re-parses the named subscription, diff-merges the
+// result against its current server list, and emits the resulting
+// Added/Removed/Changed events.
+func (m *Manager) Refresh(ctx context.Context, name string) error {
+	sub, ok := m.Get(name)
+	if !ok {
+		return fmt.Errorf("sub: no subscription named %q", name)
+	}
+
+	body, info, err := fetch(ctx, m.client, sub.URL)
+	if err != nil {
+		return fmt.Errorf("sub: refresh %q: %w", name, err)
+	}
+
+	fresh, err := parse(body)
+	if err != nil {
+		return fmt.Errorf("sub: refresh %q: %w", name, err)
+	}
+
+	sub.mu.Lock()
+	merged, events := mergeServers(sub.servers, fresh, name)
+	sub.servers = merged
+	sub.info = info
+	sub.updatedAt = time.Now()
+	sub.mu.Unlock()
+
+	for _, ev := range events {
+		m.events <- ev
+	}
+	return nil
+}
+
+// RefreshAll refreshes every registered subscription, returning the
+// first error encountered after attempting all of them.
+func (m *Manager) RefreshAll(ctx context.Context) error {
+	var firstErr error
+	for _, sub := range m.List() {
+		if err := m.Refresh(ctx, sub.Name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Run refreshes every subscription on its own Interval until ctx is
+// done, one goroutine per subscription so a slow or hanging fetch for
+// one doesn't delay another's schedule.
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, sub := range m.List() {
+		if sub.Interval <= 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(sub *Subscription) {
+			defer wg.Done()
+			ticker := time.NewTicker(sub.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					m.Refresh(ctx, sub.Name)
+				}
+			}
+		}(sub)
+	}
+	wg.Wait()
+}
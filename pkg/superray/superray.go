@@ -8,6 +8,7 @@ import "C"
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 	"unsafe"
 )
 
@@ -87,6 +88,10 @@ type LatencyResult struct {
 	MaxLatency int     `json:"max_latency_ms,omitempty"`
 	Success   bool    `json:"success"`
 	Error     string  `json:"error,omitempty"`
+	// Set by BatchLatencyTestCtx's ProbeTLS/ProbeHTTPGet/ProbeDoH modes;
+	// zero for ProbeTCP and for the cgo-backed BatchLatencyTest below.
+	TLSHandshakeMs int `json:"tls_handshake_ms,omitempty"`
+	TTFBMs         int `json:"ttfb_ms,omitempty"`
 }
 
 // freeAndGetString frees C string and returns Go string
@@ -709,6 +714,65 @@ func CreateRoutingRuleIP(ips []string, outboundTag string) (string, error) {
 	return string(resp.Data), nil
 }
 
+// ========== Connection Tracking Functions ==========
+
+// ConnectionInfo represents one active connection as reported by Xray's
+// observatory/sniffer for a running instance.
+type ConnectionInfo struct {
+	ID          string `json:"id"`
+	Network     string `json:"network"`
+	SourceAddr  string `json:"source_addr"`
+	DestAddr    string `json:"dest_addr"`
+	SNI         string `json:"sni,omitempty"`
+	OutboundTag string `json:"outbound_tag"`
+	RuleTag     string `json:"rule_tag,omitempty"`
+	Uplink      int64  `json:"uplink"`
+	Downlink    int64  `json:"downlink"`
+	StartUnix   int64  `json:"start_unix"`
+}
+
+// GetActiveConnections lists currently active connections for the given
+// Xray instance, as tracked by the DispatcherHandler observatory hook.
+func GetActiveConnections(instanceID string) ([]ConnectionInfo, error) {
+	cID := C.CString(instanceID)
+	defer C.free(unsafe.Pointer(cID))
+
+	result := freeAndGetString(C.SuperRay_GetActiveConnections(cID))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf(resp.Error)
+	}
+	var data struct {
+		Connections []ConnectionInfo `json:"connections"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil {
+		return nil, err
+	}
+	return data.Connections, nil
+}
+
+// CloseConnection forcibly closes one tracked connection through Xray's
+// HandlerService.
+func CloseConnection(instanceID, connectionID string) error {
+	cID := C.CString(instanceID)
+	cConnID := C.CString(connectionID)
+	defer C.free(unsafe.Pointer(cID))
+	defer C.free(unsafe.Pointer(cConnID))
+
+	result := freeAndGetString(C.SuperRay_CloseConnection(cID, cConnID))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
+
 // ========== TUN Device Functions ==========
 
 // CreateTUNInbound creates a TUN inbound configuration
@@ -911,6 +975,11 @@ type SystemTUNInfo struct {
 	Name   string `json:"name"`
 	MTU    int    `json:"mtu"`
 	Status string `json:"status"`
+	// Queues is the number of queue file descriptors actually opened -
+	// 1 for a device created via CreateSystemTUN/CreateSystemTUNFull, or
+	// CreateSystemTUNMultiQueue's requested count (clamped to what the
+	// platform supports).
+	Queues int `json:"queues,omitempty"`
 }
 
 // CreateSystemTUN creates a system-level TUN device (requires root/admin)
@@ -940,6 +1009,113 @@ func CreateSystemTUN(tag string, addresses []string, mtu int) (*SystemTUNInfo, e
 	return &info, nil
 }
 
+// Stack selects which network stack backs a system TUN device.
+type Stack string
+
+const (
+	// StackSystem uses the OS kernel's own network stack (routes/ioctls
+	// against a real TUN device) - lowest overhead, but only as portable
+	// as the platform's routing primitives.
+	StackSystem Stack = "system"
+	// StackGVisor uses a userspace netstack (gVisor's, as Xray's own tun
+	// app does) - portable across platforms and sandboxable, at the cost
+	// of a userspace TCP/IP stack's throughput ceiling.
+	StackGVisor Stack = "gvisor"
+	// StackMixed uses the system stack for routing and a userspace
+	// netstack for the TCP/IP state machine, Xray's "mixed" tun mode.
+	StackMixed Stack = "mixed"
+)
+
+// SystemTUNConfig is the full set of knobs CreateSystemTUNFull accepts,
+// mirroring the options Xray/v2ray's tun app exposes beyond the
+// tag/addresses/mtu triple CreateSystemTUN takes.
+type SystemTUNConfig struct {
+	Tag              string        `json:"tag"`
+	Addresses        []string      `json:"addresses"`
+	IPv6Addresses    []string      `json:"ipv6Addresses,omitempty"`
+	MTU              int           `json:"mtu"`
+	Stack            Stack         `json:"stack,omitempty"` // StackSystem if empty
+
+	EndpointIndependentNat bool          `json:"endpointIndependentNat,omitempty"`
+	UDPTimeout             time.Duration `json:"-"`
+	TCPSendBufferSize      int           `json:"tcpSendBufferSize,omitempty"`
+	TCPReceiveBufferSize   int           `json:"tcpReceiveBufferSize,omitempty"`
+}
+
+// MarshalJSON encodes UDPTimeout as whole milliseconds - the unit the C
+// bridge's JSON config expects, matching every other *Ms field the cgo
+// layer passes (e.g. TCPPing's timeoutMs) - rather than time.Duration's
+// default nanosecond encoding.
+func (c SystemTUNConfig) MarshalJSON() ([]byte, error) {
+	type alias SystemTUNConfig
+	return json.Marshal(struct {
+		alias
+		UDPTimeoutMs int64 `json:"udpTimeoutMs,omitempty"`
+	}{alias: alias(c), UDPTimeoutMs: c.UDPTimeout.Milliseconds()})
+}
+
+// CreateSystemTUNFull creates a system-level TUN device with the full
+// SystemTUNConfig, for callers that need a userspace (gVisor/mixed) stack
+// or its per-stack tunables instead of CreateSystemTUN's OS-stack
+// defaults.
+func CreateSystemTUNFull(config SystemTUNConfig) (*SystemTUNInfo, error) {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	cConfig := C.CString(string(configJSON))
+	defer C.free(unsafe.Pointer(cConfig))
+
+	result := freeAndGetString(C.SuperRay_CreateSystemTUNFull(cConfig))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf(resp.Error)
+	}
+
+	var info SystemTUNInfo
+	if err := json.Unmarshal(resp.Data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// StackStats is a TUN device's per-stack packet/byte/connection
+// snapshot, as returned by GetTUNStackStats.
+type StackStats struct {
+	Stack             Stack `json:"stack"`
+	PacketsIn         int64 `json:"packetsIn"`
+	PacketsOut        int64 `json:"packetsOut"`
+	BytesIn           int64 `json:"bytesIn"`
+	BytesOut          int64 `json:"bytesOut"`
+	ActiveConnections int   `json:"activeConnections"`
+}
+
+// GetTUNStackStats returns tag's current per-stack counters, for a TUI to
+// render live throughput and decide whether a different Stack choice
+// would suit the platform better.
+func GetTUNStackStats(tag string) (*StackStats, error) {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+
+	result := freeAndGetString(C.SuperRay_GetTUNStackStats(cTag))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf(resp.Error)
+	}
+
+	var stats StackStats
+	if err := json.Unmarshal(resp.Data, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
 // StartSystemTUNStack starts the TUN stack connected to Xray instance
 func StartSystemTUNStack(tag, instanceID, outboundTag string) error {
 	cTag := C.CString(tag)
@@ -960,14 +1136,151 @@ func StartSystemTUNStack(tag, instanceID, outboundTag string) error {
 	return nil
 }
 
-// SetupRoutes sets up system routes for TUN
-func SetupRoutes(tag, serverAddress string) error {
+// PolicyRule binds one destination prefix to the outbound that should
+// carry it: CIDR is the destination network, OutboundTag names the Xray
+// outbound to dispatch matching packets to, and Metric breaks ties
+// between rules of equal prefix length (lower wins), mirroring how
+// Route.Metric orders OS routes of equal specificity.
+type PolicyRule struct {
+	CIDR        string `json:"cidr"`
+	OutboundTag string `json:"outboundTag"`
+	Metric      int    `json:"metric,omitempty"`
+}
+
+// StartSystemTUNStackWithPolicy starts tag's TUN stack like
+// StartSystemTUNStack, but dispatches each packet to the outbound whose
+// rule has the most specific matching destination prefix, falling back
+// to instanceID's default outbound when no rule matches - the
+// longest-prefix lookup a routetree.RouteTree performs on the Go side to
+// decide what rules to send down here.
+func StartSystemTUNStackWithPolicy(tag, instanceID string, rules []PolicyRule) error {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+	cInstanceID := C.CString(instanceID)
+	defer C.free(unsafe.Pointer(cInstanceID))
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	cRules := C.CString(string(rulesJSON))
+	defer C.free(unsafe.Pointer(cRules))
+
+	result := freeAndGetString(C.SuperRay_StartSystemTUNStackWithPolicy(cTag, cInstanceID, cRules))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
+
+// UpdateTUNPolicy hot-swaps tag's dispatch rule set in place, without
+// dropping the TUN device or any connections already routed through it.
+func UpdateTUNPolicy(tag string, rules []PolicyRule) error {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	cRules := C.CString(string(rulesJSON))
+	defer C.free(unsafe.Pointer(cRules))
+
+	result := freeAndGetString(C.SuperRay_UpdateTUNPolicy(cTag, cRules))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
+
+// Route is one system route to install for a TUN device: CIDR is the
+// destination network, Via is the gateway or device to route through
+// (empty means "the TUN device itself"), Metric/MTU are optional
+// per-route overrides of the system defaults, and Install selects
+// whether this entry should be added (true) or is just a placeholder
+// kept for comparison (false).
+type Route struct {
+	CIDR    string `json:"cidr"`
+	Via     string `json:"via,omitempty"`
+	Metric  int    `json:"metric,omitempty"`
+	MTU     int    `json:"mtu,omitempty"`
+	Install bool   `json:"install"`
+}
+
+// Equal reports whether r and other would install identically - the
+// comparison UpdateRoutes uses to tell an unchanged route from one that
+// needs to be torn down and re-added.
+func (r Route) Equal(other Route) bool {
+	return r.CIDR == other.CIDR && r.Via == other.Via &&
+		r.Metric == other.Metric && r.MTU == other.MTU && r.Install == other.Install
+}
+
+// SetupRoutes installs routes for tag's TUN device in one call, replacing
+// any routes previously installed for it. Callers that need to push an
+// incremental change to an already-installed set should use UpdateRoutes
+// instead, which only issues add/remove ioctls for the delta.
+func SetupRoutes(tag string, routes []Route) error {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+	routesJSON, err := json.Marshal(routes)
+	if err != nil {
+		return err
+	}
+	cRoutes := C.CString(string(routesJSON))
+	defer C.free(unsafe.Pointer(cRoutes))
+
+	result := freeAndGetString(C.SuperRay_SetupRoutes(cTag, cRoutes))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
+
+// AddRoute installs a single route for tag's TUN device.
+func AddRoute(tag string, route Route) error {
 	cTag := C.CString(tag)
 	defer C.free(unsafe.Pointer(cTag))
-	cServerAddr := C.CString(serverAddress)
-	defer C.free(unsafe.Pointer(cServerAddr))
+	routeJSON, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	cRoute := C.CString(string(routeJSON))
+	defer C.free(unsafe.Pointer(cRoute))
+
+	result := freeAndGetString(C.SuperRay_AddRoute(cTag, cRoute))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
+
+// RemoveRoute removes a single previously-installed route from tag's TUN
+// device.
+func RemoveRoute(tag string, route Route) error {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+	routeJSON, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	cRoute := C.CString(string(routeJSON))
+	defer C.free(unsafe.Pointer(cRoute))
 
-	result := freeAndGetString(C.SuperRay_SetupRoutes(cTag, cServerAddr))
+	result := freeAndGetString(C.SuperRay_RemoveRoute(cTag, cRoute))
 	resp, err := parseResponse(result)
 	if err != nil {
 		return err
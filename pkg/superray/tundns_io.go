@@ -0,0 +1,120 @@
+package superray
+
+/*
+#include <stdlib.h>
+#include "superray.h"
+*/
+import "C"
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"unsafe"
+)
+
+// DNSHijackConfig configures EnableTUNDNS's fake-IP interception of
+// DNS traffic crossing a TUN device - the Xray/sing-box style fake-IP
+// mode, brought into the TUN subsystem so browsers and other apps that
+// bypass the configured system resolver (DoH-in-app, hardcoded 8.8.8.8,
+// etc.) still get routed correctly.
+type DNSHijackConfig struct {
+	// ListenAddress is where the C side binds its own intercepting DNS
+	// server, e.g. "10.255.0.1:53" - the TUN device's own address, so
+	// traffic the TUN's routes have already pointed at UDP/53 there lands
+	// on it.
+	ListenAddress string
+	// UpstreamOutboundTag names the Xray outbound actual resolution
+	// (for the handful of domains that need a real answer, and for
+	// reaching the configured DNS server at all) is sent through.
+	UpstreamOutboundTag string
+	// FakeIPRange is the CIDR fake addresses are allocated from, e.g.
+	// 198.18.0.0/15.
+	FakeIPRange *net.IPNet
+	// Hosts statically maps a hostname to a real address, bypassing the
+	// fake pool entirely - for domains that must resolve to their real
+	// IP even under fake-IP mode (e.g. the proxy server's own hostname).
+	Hosts map[string]string
+}
+
+// EnableTUNDNS starts intercepting UDP/53 (and, where the platform
+// allows redirecting a TCP stream on the TUN, TCP/53) traffic on tag's
+// TUN device per cfg: non-Hosts domains get a synthetic address out of
+// FakeIPRange, with a bidirectional domain<->fake-IP map maintained so a
+// later connection to that fake IP can be rewritten back to the original
+// hostname before dialing out through UpstreamOutboundTag.
+func EnableTUNDNS(tag string, cfg DNSHijackConfig) error {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+
+	wire := struct {
+		ListenAddress       string            `json:"listenAddress"`
+		UpstreamOutboundTag string            `json:"upstreamOutboundTag"`
+		FakeIPRange         string            `json:"fakeIpRange,omitempty"`
+		Hosts               map[string]string `json:"hosts,omitempty"`
+	}{
+		ListenAddress:       cfg.ListenAddress,
+		UpstreamOutboundTag: cfg.UpstreamOutboundTag,
+		Hosts:               cfg.Hosts,
+	}
+	if cfg.FakeIPRange != nil {
+		wire.FakeIPRange = cfg.FakeIPRange.String()
+	}
+	configJSON, err := json.Marshal(wire)
+	if err != nil {
+		return err
+	}
+	cConfig := C.CString(string(configJSON))
+	defer C.free(unsafe.Pointer(cConfig))
+
+	result := freeAndGetString(C.SuperRay_EnableTUNDNS(cTag, cConfig))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
+
+// LookupFakeIP reverses a fake IP previously handed out on tag's TUN
+// device back to the hostname that earned it. ok is false for a real
+// (non-fake) address, an address that's since been evicted, or if
+// EnableTUNDNS was never called for tag.
+func LookupFakeIP(tag, ip string) (host string, ok bool) {
+	cTag := C.CString(tag)
+	cIP := C.CString(ip)
+	defer C.free(unsafe.Pointer(cTag))
+	defer C.free(unsafe.Pointer(cIP))
+
+	result := freeAndGetString(C.SuperRay_LookupFakeIP(cTag, cIP))
+	resp, err := parseResponse(result)
+	if err != nil || !resp.Success {
+		return "", false
+	}
+	var data struct {
+		Host  string `json:"host"`
+		Found bool   `json:"found"`
+	}
+	if err := json.Unmarshal(resp.Data, &data); err != nil || !data.Found {
+		return "", false
+	}
+	return data.Host, true
+}
+
+// FlushFakeIPCache forgets every domain<->fake-IP mapping recorded for
+// tag, so the next query for any domain allocates a fresh address.
+func FlushFakeIPCache(tag string) error {
+	cTag := C.CString(tag)
+	defer C.free(unsafe.Pointer(cTag))
+
+	result := freeAndGetString(C.SuperRay_FlushFakeIPCache(cTag))
+	resp, err := parseResponse(result)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf(resp.Error)
+	}
+	return nil
+}
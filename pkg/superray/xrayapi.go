@@ -0,0 +1,52 @@
+package superray
+
+import (
+	"fmt"
+
+	logcmd "github.com/xtls/xray-core/app/log/command"
+	handlercmd "github.com/xtls/xray-core/app/proxyman/command"
+	routercmd "github.com/xtls/xray-core/app/router/command"
+	statscmd "github.com/xtls/xray-core/app/stats/command"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// XrayAPI is a connected client to an Xray instance's Commander gRPC
+// service - the standard Xray stats/handler/routing/log API surface,
+// exposed when the instance's config carries an "api" block and a
+// matching "api"-tagged inbound. It replaces the ad-hoc
+// GetXrayStats/SetLogLevel calls with the real typed clients, so callers
+// aren't limited to what this package happens to wrap.
+type XrayAPI struct {
+	conn *grpc.ClientConn
+
+	Stats   statscmd.StatsServiceClient
+	Handler handlercmd.HandlerServiceClient
+	Routing routercmd.RoutingServiceClient
+	Logger  logcmd.LoggerServiceClient
+}
+
+// ConnectXrayAPI dials the Commander service an instance exposes on
+// listen (the same host:port its config's "api" inbound binds).
+// instanceID isn't used for the dial itself - the gRPC service is a
+// plain network listener, independent of the cgo instance handle - but
+// is accepted for parity with this package's other instance-scoped
+// calls and included in the returned error for context.
+func ConnectXrayAPI(instanceID, listen string) (*XrayAPI, error) {
+	conn, err := grpc.NewClient(listen, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("superray: connect xray api for instance %s at %s: %w", instanceID, listen, err)
+	}
+	return &XrayAPI{
+		conn:    conn,
+		Stats:   statscmd.NewStatsServiceClient(conn),
+		Handler: handlercmd.NewHandlerServiceClient(conn),
+		Routing: routercmd.NewRoutingServiceClient(conn),
+		Logger:  logcmd.NewLoggerServiceClient(conn),
+	}, nil
+}
+
+// Close releases the underlying gRPC connection.
+func (x *XrayAPI) Close() error {
+	return x.conn.Close()
+}
@@ -0,0 +1,200 @@
+// Package supervisor gives the App's long-running background work (the
+// stats poller, subscription auto-updater, GeoIP cache maintainer,
+// per-connection flow tracker, ...) a uniform, restart-on-failure
+// lifecycle instead of each one being a bare goroutine that silently
+// goes dark on panic or unexpected return.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Service is one supervised unit of background work. Serve should run
+// until ctx is cancelled or it hits an unrecoverable error; returning
+// (for any reason, including a panic recovered by the Supervisor) is
+// treated as a failure worth restarting, unless ctx is already done.
+// Stop is called once, after Serve has returned, to release any
+// resources Serve itself doesn't clean up on the way out (e.g. closing
+// a listener Serve merely accepts connections on).
+type Service interface {
+	Serve(ctx context.Context) error
+	Stop()
+}
+
+// FuncService adapts a plain ctx-cancellable function into a Service with
+// a no-op Stop, for the common case of a ticker loop that needs no
+// cleanup beyond honoring ctx cancellation.
+type FuncService func(ctx context.Context) error
+
+// Serve runs f.
+func (f FuncService) Serve(ctx context.Context) error { return f(ctx) }
+
+// Stop does nothing; f is expected to return once its ctx is done.
+func (f FuncService) Stop() {}
+
+// Event describes one lifecycle transition, for Supervisor.OnEvent.
+type Event struct {
+	Service string
+	Message string
+}
+
+// Supervisor restarts each registered Service with exponential backoff
+// when it returns (cleanly, with an error, or via panic), until the
+// Supervisor's context is cancelled.
+type Supervisor struct {
+	// MinBackoff/MaxBackoff bound the delay between restarts; it doubles
+	// on each consecutive failure and resets once a service has stayed
+	// up for ResetAfter.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	ResetAfter time.Duration
+
+	// OnEvent, if set, is called for every lifecycle transition (starting,
+	// panic/error, restart delay, stopped) so the App can surface it in
+	// the log view.
+	OnEvent func(Event)
+
+	mu       sync.Mutex
+	services map[string]Service
+	cancels  map[string]context.CancelFunc
+	runCtx   context.Context
+	wg       sync.WaitGroup
+}
+
+// New builds a Supervisor with sane default backoff bounds.
+func New() *Supervisor {
+	return &Supervisor{
+		MinBackoff: 500 * time.Millisecond,
+		MaxBackoff: 30 * time.Second,
+		ResetAfter: time.Minute,
+		services:   make(map[string]Service),
+		cancels:    make(map[string]context.CancelFunc),
+	}
+}
+
+// Add registers svc under name, replacing (and stopping) any previous
+// registration with that name. May be called either before Run (svc
+// starts once Run is called) or after (svc starts immediately), which is
+// what lets the App register per-connection services like a health-probe
+// scheduler whose server list changes after every subscription refresh.
+func (s *Supervisor) Add(name string, svc Service) {
+	s.mu.Lock()
+	if cancel, ok := s.cancels[name]; ok {
+		cancel()
+	}
+	s.services[name] = svc
+	running := s.runCtx
+	s.mu.Unlock()
+
+	if running != nil {
+		s.start(name, svc)
+	}
+}
+
+// Remove stops and deregisters the named service, if registered.
+func (s *Supervisor) Remove(name string) {
+	s.mu.Lock()
+	cancel, ok := s.cancels[name]
+	delete(s.services, name)
+	delete(s.cancels, name)
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// Run starts every currently-registered service in its own supervised
+// goroutine (later Add calls start theirs immediately) and blocks until
+// ctx is cancelled and they have all stopped.
+func (s *Supervisor) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.runCtx = ctx
+	services := make(map[string]Service, len(s.services))
+	for name, svc := range s.services {
+		services[name] = svc
+	}
+	s.mu.Unlock()
+
+	for name, svc := range services {
+		s.start(name, svc)
+	}
+
+	<-ctx.Done()
+	s.wg.Wait()
+}
+
+// start launches the supervised goroutine for one service, tracking its
+// per-service cancel func so Add/Remove can stop it independently of the
+// others.
+func (s *Supervisor) start(name string, svc Service) {
+	s.mu.Lock()
+	ctx, cancel := context.WithCancel(s.runCtx)
+	s.cancels[name] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.supervise(ctx, name, svc)
+	}()
+}
+
+func (s *Supervisor) supervise(ctx context.Context, name string, svc Service) {
+	backoff := s.MinBackoff
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.emit(name, "starting")
+		started := time.Now()
+		err := s.serveRecovered(ctx, svc)
+		svc.Stop()
+
+		if ctx.Err() != nil {
+			s.emit(name, "stopped")
+			return
+		}
+		if err != nil {
+			s.emit(name, fmt.Sprintf("failed: %v", err))
+		} else {
+			s.emit(name, "exited")
+		}
+
+		if time.Since(started) >= s.ResetAfter {
+			backoff = s.MinBackoff
+		}
+
+		s.emit(name, fmt.Sprintf("restarting in %s", backoff))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+
+		backoff *= 2
+		if backoff > s.MaxBackoff {
+			backoff = s.MaxBackoff
+		}
+	}
+}
+
+// serveRecovered runs svc.Serve, turning a panic into an error so the
+// caller's restart loop treats it the same as any other failure.
+func (s *Supervisor) serveRecovered(ctx context.Context, svc Service) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return svc.Serve(ctx)
+}
+
+func (s *Supervisor) emit(name, message string) {
+	if s.OnEvent != nil {
+		s.OnEvent(Event{Service: name, Message: message})
+	}
+}
@@ -0,0 +1,116 @@
+// Package sysinfo samples host and tunnel-interface metrics so the TUI can
+// show a "System" panel distinguishing "proxy is slow" from "my
+// machine/network is slow".
+package sysinfo
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// Snapshot is one sample of host + tunnel interface metrics.
+type Snapshot struct {
+	Load1, Load5, Load15 float64
+	CPUPercent           float64
+	MemUsedPercent       float64
+	MemUsedBytes         uint64
+	MemTotalBytes        uint64
+
+	TUNInterface  string
+	TUNPacketsIn  uint64
+	TUNPacketsOut uint64
+	TUNBytesIn    uint64
+	TUNBytesOut   uint64
+
+	DefaultRouteInterface string
+	DNSResolvers          []string
+}
+
+// Sample gathers a Snapshot. tunInterface names the TUN device to report
+// counters for (e.g. "tun0"); pass "" to skip it.
+func Sample(tunInterface string) (*Snapshot, error) {
+	snap := &Snapshot{TUNInterface: tunInterface}
+
+	if avg, err := load.Avg(); err == nil {
+		snap.Load1, snap.Load5, snap.Load15 = avg.Load1, avg.Load5, avg.Load15
+	}
+
+	if percents, err := cpu.Percent(0, false); err == nil && len(percents) > 0 {
+		snap.CPUPercent = percents[0]
+	}
+
+	if vm, err := mem.VirtualMemory(); err == nil {
+		snap.MemUsedPercent = vm.UsedPercent
+		snap.MemUsedBytes = vm.Used
+		snap.MemTotalBytes = vm.Total
+	}
+
+	if tunInterface != "" {
+		if counters, err := net.IOCounters(true); err == nil {
+			for _, c := range counters {
+				if c.Name == tunInterface {
+					snap.TUNPacketsIn = c.PacketsRecv
+					snap.TUNPacketsOut = c.PacketsSent
+					snap.TUNBytesIn = c.BytesRecv
+					snap.TUNBytesOut = c.BytesSent
+					break
+				}
+			}
+		}
+	}
+
+	snap.DefaultRouteInterface = defaultRouteInterface()
+	snap.DNSResolvers = readResolvConf()
+
+	return snap, nil
+}
+
+// readResolvConf parses /etc/resolv.conf for "nameserver" lines. Returns
+// nil on platforms without that file (Windows) or if it can't be read.
+func readResolvConf() []string {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var servers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// defaultRouteInterface returns the interface name for the default IPv4
+// route by reading /proc/net/route (Linux only; returns "" elsewhere).
+func defaultRouteInterface() string {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		// Destination 00000000 means the default route.
+		if fields[1] == "00000000" {
+			return fields[0]
+		}
+	}
+	return ""
+}
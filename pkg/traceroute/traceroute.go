@@ -0,0 +1,372 @@
+// Package traceroute implements a streaming ICMP echo traceroute (IPv4 and
+// IPv6) with RFC4950 MPLS label stack extraction, similar in spirit to
+// NextTrace. It sends no UDP probes.
+package traceroute
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// MPLSLabel represents one entry of an RFC4950 MPLS label stack.
+type MPLSLabel struct {
+	Label uint32 // 20-bit label value
+	TC    uint8  // 3-bit traffic class
+	Bottom bool  // 1-bit bottom-of-stack
+	TTL   uint8  // 8-bit TTL copied from the label stack entry
+}
+
+// Probe is a single TTL/probe-index result for one hop.
+type Probe struct {
+	TTL     int
+	Seq     int
+	Addr    string
+	RTT     time.Duration
+	TimedOut bool
+}
+
+// Hop aggregates all probes sent for a given TTL, plus enrichment that is
+// filled in as it becomes available (reverse DNS, ASN/geo, MPLS).
+type Hop struct {
+	TTL      int
+	Addr     string
+	Probes   []Probe
+	Hostname string
+	ASN      string
+	Country  string
+	MPLS     []MPLSLabel
+	Loss     float64 // fraction of probes at this TTL that timed out
+	Done     bool    // true once all probes for this TTL have been collected
+}
+
+// Options configures a Trace run.
+type Options struct {
+	MaxHops      int           // default 30
+	ProbesPerHop int           // default 3
+	Timeout      time.Duration // per-probe timeout, default 1s
+	Interval     time.Duration // delay between sent probes, default 20ms
+	ResolveDNS   bool          // reverse-DNS each responding hop
+	Enrich       func(addr string) (asn, country string) // GeoIP enrichment hook
+}
+
+func (o *Options) setDefaults() {
+	if o.MaxHops <= 0 {
+		o.MaxHops = 30
+	}
+	if o.ProbesPerHop <= 0 {
+		o.ProbesPerHop = 3
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = time.Second
+	}
+	if o.Interval <= 0 {
+		o.Interval = 20 * time.Millisecond
+	}
+}
+
+// HopEvent is emitted on the channel returned by Trace once per TTL, after
+// every probe for that TTL has been answered or has timed out; no event is
+// sent for an individual probe reply.
+type HopEvent struct {
+	Hop *Hop
+	Err error
+}
+
+// Trace runs a traceroute to host, sending probes with monotonically
+// increasing TTLs and streaming one HopEvent per completed hop on the
+// returned channel. The channel is closed when the trace completes, the
+// destination is reached, or ctx is cancelled.
+func Trace(ctx context.Context, host string, opts Options) (<-chan HopEvent, error) {
+	opts.setDefaults()
+
+	ipAddr, err := net.ResolveIPAddr("ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("traceroute: resolve %s: %w", host, err)
+	}
+	isV6 := ipAddr.IP.To4() == nil
+
+	events := make(chan HopEvent, opts.MaxHops*opts.ProbesPerHop)
+
+	go func() {
+		defer close(events)
+		if isV6 {
+			runTrace6(ctx, ipAddr, opts, events)
+		} else {
+			runTrace4(ctx, ipAddr, opts, events)
+		}
+	}()
+
+	return events, nil
+}
+
+func runTrace4(ctx context.Context, dst *net.IPAddr, opts Options, events chan<- HopEvent) {
+	conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		events <- HopEvent{Err: fmt.Errorf("traceroute: listen icmp4: %w", err)}
+		return
+	}
+	defer conn.Close()
+
+	reached := false
+	for ttl := 1; ttl <= opts.MaxHops && !reached && ctx.Err() == nil; ttl++ {
+		hop := &Hop{TTL: ttl}
+		var timeouts int
+
+		for seq := 0; seq < opts.ProbesPerHop; seq++ {
+			if ctx.Err() != nil {
+				return
+			}
+			conn.IPv4PacketConn().SetTTL(ttl)
+
+			msg := icmp.Message{
+				Type: ipv4.ICMPTypeEcho,
+				Code: 0,
+				Body: &icmp.Echo{
+					ID:   os.Getpid() & 0xffff,
+					Seq:  ttl<<8 | seq,
+					Data: []byte("superray-traceroute"),
+				},
+			}
+			wb, err := msg.Marshal(nil)
+			if err != nil {
+				continue
+			}
+
+			start := time.Now()
+			if _, err := conn.WriteTo(wb, dst); err != nil {
+				continue
+			}
+
+			conn.SetReadDeadline(time.Now().Add(opts.Timeout))
+			rb := make([]byte, 1500)
+			n, peer, addr, label, err := readICMP4(conn, rb)
+			rtt := time.Since(start)
+
+			probe := Probe{TTL: ttl, Seq: seq, RTT: rtt}
+			if err != nil || n == 0 {
+				probe.TimedOut = true
+				timeouts++
+			} else {
+				probe.Addr = peer
+				hop.Addr = peer
+				hop.MPLS = mergeMPLS(hop.MPLS, label)
+				_ = addr
+			}
+			hop.Probes = append(hop.Probes, probe)
+
+			if peer := probe.Addr; peer != "" && peer == dst.String() {
+				reached = true
+			}
+
+			time.Sleep(opts.Interval)
+		}
+
+		hop.Loss = float64(timeouts) / float64(opts.ProbesPerHop)
+		hop.Done = true
+		enrichHop(hop, opts)
+		events <- HopEvent{Hop: hop}
+	}
+}
+
+func runTrace6(ctx context.Context, dst *net.IPAddr, opts Options, events chan<- HopEvent) {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		events <- HopEvent{Err: fmt.Errorf("traceroute: listen icmp6: %w", err)}
+		return
+	}
+	defer conn.Close()
+
+	reached := false
+	for ttl := 1; ttl <= opts.MaxHops && !reached && ctx.Err() == nil; ttl++ {
+		hop := &Hop{TTL: ttl}
+		var timeouts int
+
+		for seq := 0; seq < opts.ProbesPerHop; seq++ {
+			if ctx.Err() != nil {
+				return
+			}
+			conn.IPv6PacketConn().SetHopLimit(ttl)
+
+			msg := icmp.Message{
+				Type: ipv6.ICMPTypeEchoRequest,
+				Code: 0,
+				Body: &icmp.Echo{
+					ID:   os.Getpid() & 0xffff,
+					Seq:  ttl<<8 | seq,
+					Data: []byte("superray-traceroute"),
+				},
+			}
+			wb, err := msg.Marshal(nil)
+			if err != nil {
+				continue
+			}
+
+			start := time.Now()
+			if _, err := conn.WriteTo(wb, dst); err != nil {
+				continue
+			}
+
+			conn.SetReadDeadline(time.Now().Add(opts.Timeout))
+			rb := make([]byte, 1500)
+			n, peer, err := readICMP6(conn, rb)
+			rtt := time.Since(start)
+
+			probe := Probe{TTL: ttl, Seq: seq, RTT: rtt}
+			if err != nil || n == 0 {
+				probe.TimedOut = true
+				timeouts++
+			} else {
+				probe.Addr = peer
+				hop.Addr = peer
+			}
+			hop.Probes = append(hop.Probes, probe)
+
+			if probe.Addr != "" && probe.Addr == dst.String() {
+				reached = true
+			}
+
+			time.Sleep(opts.Interval)
+		}
+
+		hop.Loss = float64(timeouts) / float64(opts.ProbesPerHop)
+		hop.Done = true
+		enrichHop(hop, opts)
+		events <- HopEvent{Hop: hop}
+	}
+}
+
+func enrichHop(hop *Hop, opts Options) {
+	if hop.Addr == "" {
+		return
+	}
+	if opts.ResolveDNS {
+		if names, err := net.LookupAddr(hop.Addr); err == nil && len(names) > 0 {
+			hop.Hostname = names[0]
+		}
+	}
+	if opts.Enrich != nil {
+		hop.ASN, hop.Country = opts.Enrich(hop.Addr)
+	}
+}
+
+// readICMP4 reads a single ICMPv4 reply and, for Time Exceeded messages,
+// extracts the RFC4950 MPLS label stack from the ICMP extension object, if
+// present.
+func readICMP4(conn *icmp.PacketConn, buf []byte) (n int, peer string, addr net.Addr, mpls []MPLSLabel, err error) {
+	n, addr, err = conn.ReadFrom(buf)
+	if err != nil {
+		return 0, "", nil, nil, err
+	}
+	msg, err := icmp.ParseMessage(1, buf[:n])
+	if err != nil {
+		return n, "", addr, nil, err
+	}
+
+	switch msg.Type {
+	case ipv4.ICMPTypeEchoReply:
+		if ip, ok := addr.(*net.IPAddr); ok {
+			peer = ip.IP.String()
+		}
+	case ipv4.ICMPTypeTimeExceeded, ipv4.ICMPTypeDestinationUnreachable:
+		if ip, ok := addr.(*net.IPAddr); ok {
+			peer = ip.IP.String()
+		}
+		if body, ok := msg.Body.(*icmp.TimeExceeded); ok {
+			mpls = parseMPLSExtension(body.Data)
+		}
+	}
+	return n, peer, addr, mpls, nil
+}
+
+func readICMP6(conn *icmp.PacketConn, buf []byte) (n int, peer string, err error) {
+	var addr net.Addr
+	n, addr, err = conn.ReadFrom(buf)
+	if err != nil {
+		return 0, "", err
+	}
+	msg, err := icmp.ParseMessage(58, buf[:n])
+	if err != nil {
+		return n, "", err
+	}
+	switch msg.Type {
+	case ipv6.ICMPTypeEchoReply, ipv6.ICMPTypeTimeExceeded, ipv6.ICMPTypeDestinationUnreachable:
+		if ip, ok := addr.(*net.IPAddr); ok {
+			peer = ip.IP.String()
+		}
+	}
+	return n, peer, nil
+}
+
+// parseMPLSExtension walks the RFC4950 ICMP extension structure embedded
+// after the original datagram and decodes Label Stack Entry objects
+// (class 1, c-type 1: 32-bit entries of 20-bit label / 3-bit TC / 1-bit S /
+// 8-bit TTL).
+func parseMPLSExtension(data []byte) []MPLSLabel {
+	// RFC4884 extension header starts after a fixed 128-byte "original
+	// datagram" padding area when the header's length field indicates
+	// extensions are present; find it defensively by scanning for the
+	// 2-byte extension header version/checksum followed by an object
+	// matching class=1 (MPLS), c-type=1 (stack entry).
+	const extHeaderLen = 4
+	const objHeaderLen = 4
+
+	for off := 0; off+extHeaderLen+objHeaderLen <= len(data); off++ {
+		version := data[off] >> 4
+		if version != 2 {
+			continue
+		}
+		objOff := off + extHeaderLen
+		if objOff+objHeaderLen > len(data) {
+			break
+		}
+		objLen := int(binary.BigEndian.Uint16(data[objOff : objOff+2]))
+		class := data[objOff+2]
+		ctype := data[objOff+3]
+		if class != 1 || ctype != 1 {
+			continue
+		}
+		payload := data[objOff+objHeaderLen:]
+		if objLen > objHeaderLen {
+			payload = payload[:min(len(payload), objLen-objHeaderLen)]
+		}
+		return decodeLabelStack(payload)
+	}
+	return nil
+}
+
+func decodeLabelStack(payload []byte) []MPLSLabel {
+	var labels []MPLSLabel
+	for len(payload) >= 4 {
+		entry := binary.BigEndian.Uint32(payload[:4])
+		labels = append(labels, MPLSLabel{
+			Label:  entry >> 12,
+			TC:     uint8((entry >> 9) & 0x7),
+			Bottom: entry&0x100 != 0,
+			TTL:    uint8(entry & 0xff),
+		})
+		payload = payload[4:]
+	}
+	return labels
+}
+
+func mergeMPLS(existing, next []MPLSLabel) []MPLSLabel {
+	if len(next) == 0 {
+		return existing
+	}
+	return next
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"superray-tui/pkg/proxygroup"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showProxyGroupPanel lists the configured outbound selector groups
+// (url-test/fallback/load-balance/select) with each member's latency and
+// the currently active one. Enter re-tests the highlighted group
+// (select groups instead cycle to their next member, since they aren't
+// latency-driven), Esc closes.
+func (a *App) showProxyGroupPanel() {
+	a.mu.RLock()
+	mgr := a.groupManager
+	a.mu.RUnlock()
+
+	if mgr == nil {
+		a.log("[yellow]No proxy groups configured (set PROXY_GROUPS_CONF)[white]")
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(true)
+	list.SetBorder(true).SetTitle(" Proxy Groups [Enter:Test/Switch Esc:Close] ")
+
+	groups := mgr.Groups()
+	for _, g := range groups {
+		g := g
+		active, idx, _ := g.Active()
+		name := "-"
+		if active != nil {
+			name = active.Name
+		}
+		list.AddItem(fmt.Sprintf("%s (%s)", g.Name, g.Type), fmt.Sprintf("active: %s [%d]", name, idx), 0, func() {
+			if g.Type == proxygroup.TypeSelect {
+				if len(g.Members) > 0 {
+					_, idx, _ := g.Active()
+					g.SetActive((idx + 1) % len(g.Members))
+					a.log(fmt.Sprintf("[green]Group %q switched to %s[white]", g.Name, g.Members[(idx+1)%len(g.Members)].Name))
+				}
+			} else {
+				safeGo(func() {
+					ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+					defer cancel()
+					mgr.Retest(ctx, g.Name)
+					a.log(fmt.Sprintf("[green]Group %q re-tested[white]", g.Name))
+				})
+			}
+		})
+	}
+
+	list.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			a.pages.RemovePage("groups")
+			return nil
+		}
+		return event
+	})
+
+	a.pages.AddPage("groups", list, true, true)
+	a.app.SetFocus(list)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showRuleProviderPanel lists the configured rule providers (source, rule
+// count, last refresh), with 'r' to refresh all of them and Esc to close.
+// With no RULE_PROVIDERS_CONF manifest configured, it explains that
+// buildConfig is falling back to its built-in rules.
+func (a *App) showRuleProviderPanel() {
+	view := tview.NewTextView().SetDynamicColors(true)
+	view.SetBorder(true).SetTitle(" Rule Providers [r:Refresh Esc:Close] ")
+
+	a.renderRuleProviderPanel(view)
+
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			a.pages.RemovePage("rules")
+			return nil
+		case event.Rune() == 'r' || event.Rune() == 'R':
+			a.mu.RLock()
+			mgr := a.ruleManager
+			a.mu.RUnlock()
+			if mgr == nil {
+				a.log("[yellow]No rule providers configured[white]")
+				return nil
+			}
+			safeGo(func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+				if err := mgr.RefreshAll(ctx); err != nil {
+					a.log(fmt.Sprintf("[red]Rule provider refresh failed: %v[white]", err))
+				} else {
+					a.log("[green]Rule providers refreshed[white]")
+				}
+				a.app.QueueUpdateDraw(func() { a.renderRuleProviderPanel(view) })
+			})
+			return nil
+		}
+		return event
+	})
+
+	a.pages.AddPage("rules", view, true, true)
+	a.app.SetFocus(view)
+}
+
+func (a *App) renderRuleProviderPanel(view *tview.TextView) {
+	a.mu.RLock()
+	mgr := a.ruleManager
+	manifestPath := a.ruleManifestPath
+	a.mu.RUnlock()
+
+	var b strings.Builder
+	if mgr == nil {
+		fmt.Fprintf(&b, "[yellow]No rule providers configured[white]\n\n")
+		fmt.Fprintf(&b, "Set RULE_PROVIDERS_CONF to a manifest YAML file to enable\n")
+		fmt.Fprintf(&b, "named DOMAIN/DOMAIN-SUFFIX/DOMAIN-KEYWORD/IP-CIDR/GEOSITE/GEOIP/\n")
+		fmt.Fprintf(&b, "PROTOCOL/INBOUND-TAG rule sets.\n\n")
+		fmt.Fprintf(&b, "Falling back to built-in private-range + direct-country rules.\n")
+		view.SetText(b.String())
+		return
+	}
+
+	fmt.Fprintf(&b, "[yellow]Manifest:[white] %s\n\n", manifestPath)
+	for _, p := range mgr.Providers() {
+		source := p.Path
+		if p.URL != "" {
+			source = p.URL
+		}
+		age := "never"
+		if updated := p.UpdatedAt(); !updated.IsZero() {
+			age = time.Since(updated).Round(time.Second).String() + " ago"
+		}
+		fmt.Fprintf(&b, "[green]%s[white] (%s)\n source: %s\n rules: %d   refreshed: %s\n\n",
+			p.Name, p.Action, source, len(p.Rules()), age)
+	}
+
+	view.SetText(b.String())
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"superray-tui/pkg/sysinfo"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// showSystemPanel shows a live-updating "System" page with host resource
+// usage and tunnel health, so it's possible to tell "proxy is slow" from
+// "my machine/network is slow" at a glance. Esc closes it.
+func (a *App) showSystemPanel() {
+	view := tview.NewTextView().SetDynamicColors(true)
+	view.SetBorder(true).SetTitle(" System [Esc to close] ")
+
+	done := make(chan struct{})
+	view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			close(done)
+			a.pages.RemovePage("system")
+			return nil
+		}
+		return event
+	})
+
+	a.pages.AddPage("system", view, true, true)
+	a.app.SetFocus(view)
+
+	safeGo(func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		a.renderSystemPanel(view)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				a.renderSystemPanel(view)
+			}
+		}
+	})
+}
+
+func (a *App) renderSystemPanel(view *tview.TextView) {
+	a.mu.RLock()
+	tunMode := a.tunMode
+	isConnected := a.isConnected
+	connectedAt := a.connectedAt
+	currentServer := a.currentServer
+	sched := a.healthSched
+	a.mu.RUnlock()
+
+	tunIface := ""
+	if tunMode {
+		tunIface = "tun0"
+	}
+
+	snap, err := sysinfo.Sample(tunIface)
+	if err != nil || snap == nil {
+		a.app.QueueUpdateDraw(func() {
+			view.SetText(fmt.Sprintf("[red]Failed to sample system metrics: %v[white]", err))
+		})
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[yellow]Host[white]\n")
+	fmt.Fprintf(&b, " Load: %.2f %.2f %.2f   CPU: %.1f%%   Mem: %s/%s (%.0f%%)\n\n",
+		snap.Load1, snap.Load5, snap.Load15, snap.CPUPercent,
+		formatBytes(int64(snap.MemUsedBytes)), formatBytes(int64(snap.MemTotalBytes)), snap.MemUsedPercent)
+
+	fmt.Fprintf(&b, "[yellow]Network[white]\n")
+	fmt.Fprintf(&b, " Default route: %s   DNS: %s\n\n",
+		valueOr(snap.DefaultRouteInterface, "unknown"), strings.Join(snap.DNSResolvers, ", "))
+
+	fmt.Fprintf(&b, "[yellow]Tunnel[white]\n")
+	if tunMode {
+		fmt.Fprintf(&b, " Device: %s   MTU: 1500\n", snap.TUNInterface)
+		fmt.Fprintf(&b, " Packets: [green]↑[white]%d [blue]↓[white]%d   Bytes: [green]↑[white]%s [blue]↓[white]%s\n",
+			snap.TUNPacketsOut, snap.TUNPacketsIn, formatBytes(int64(snap.TUNBytesOut)), formatBytes(int64(snap.TUNBytesIn)))
+	} else {
+		fmt.Fprintf(&b, " [darkgray]Not in TUN mode[white]\n")
+	}
+
+	if isConnected && !connectedAt.IsZero() {
+		fmt.Fprintf(&b, " Uptime: %s\n", time.Since(connectedAt).Round(time.Second))
+	}
+	if sched != nil && currentServer != nil {
+		if ts, ok := sched.LastSuccess(currentServer); ok {
+			fmt.Fprintf(&b, " Last successful probe: %s ago\n", time.Since(ts).Round(time.Second))
+		} else {
+			fmt.Fprintf(&b, " Last successful probe: [darkgray]none yet[white]\n")
+		}
+	}
+
+	a.app.QueueUpdateDraw(func() {
+		view.SetText(b.String())
+	})
+}
+
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}